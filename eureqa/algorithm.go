@@ -0,0 +1,60 @@
+package eureqa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Algorithm is a search engine for equations which fit a DataSet,
+// so different engines can be run and compared on the same data
+type Algorithm interface {
+	// Run searches until p.Gens generations or ctx is cancelled,
+	// engines tell the observers what they can of their progress
+	Run(ctx context.Context, data *DataSet, p *Params, obs ...Observer) (*Result, error)
+}
+
+var algorithms = make(map[string]func() Algorithm)
+
+// Register makes an algorithm available by name,
+// engines in other packages call it from init
+func Register(name string, newAlg func() Algorithm) {
+	if _, dup := algorithms[name]; dup {
+		panic("eureqa: algorithm " + name + " registered twice")
+	}
+	algorithms[name] = newAlg
+}
+
+// NewAlgorithm returns the algorithm registered as name
+func NewAlgorithm(name string) (Algorithm, error) {
+	newAlg, ok := algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm %q, have %v", name, Algorithms())
+	}
+	return newAlg(), nil
+}
+
+// Algorithms are the names of the registered algorithms, sorted
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("island", func() Algorithm { return islandAlgorithm{} })
+}
+
+// islandAlgorithm is the island model Search of this package
+type islandAlgorithm struct{}
+
+func (islandAlgorithm) Run(ctx context.Context, data *DataSet, p *Params, obs ...Observer) (*Result, error) {
+	opts := []Option{WithParams(p)}
+	for _, o := range obs {
+		opts = append(opts, WithObserver(o))
+	}
+	return NewSearch(data, opts...).Run(ctx)
+}
@@ -0,0 +1,219 @@
+package eureqa
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+	"os"
+	"unsafe"
+)
+
+// The cache of a data file, name.cache, holds its table in little endian:
+//
+//	magic "EQDC", version       4 bytes, uint32
+//	data file size, mod time    int64, int64 unix nanoseconds
+//	delimiter option            int32, 0 when detected
+//	rows, columns, system       uint32 each
+//	column names, system names  uint32 length and the bytes of each
+//	system values               float64 each
+//	padding                     zeros to a multiple of 8 bytes
+//	columns                     float64 each, a column after another
+//
+// so the columns can be used where they lie in the mapped file.
+
+const (
+	cacheMagic   = "EQDC"
+	cacheVersion = 2 // 2 keeps the NaNs of the file apart
+)
+
+func cacheName(filename string) string { return filename + ".cache" }
+
+// readCache maps the cache of filename into memory, nil when there is
+// none or it is stale, for another file or another delimiter option.
+// The mapping is private, so filling missing values doesn't write to the
+// file, and it stays mapped while the program runs.
+func readCache(filename string, delim rune) *table {
+	st, err := os.Stat(filename)
+	if err != nil {
+		return nil
+	}
+	b, err := mapFile(cacheName(filename))
+	if err != nil {
+		return nil
+	}
+	t := decodeCache(b, st, delim)
+	if t == nil {
+		unmapFile(b)
+	}
+	return t
+}
+
+// decodeCache is the table in cache b of the data file st
+// read with delim, nil if it is stale or damaged
+func decodeCache(b []byte, st os.FileInfo, delim rune) *table {
+	c := &cacheReader{b: b}
+
+	if string(c.next(4)) != cacheMagic || c.uint32() != cacheVersion {
+		return nil
+	}
+	if c.int64() != st.Size() || c.int64() != st.ModTime().UnixNano() || rune(c.uint32()) != delim {
+		return nil
+	}
+	rows, ncols, nsys := int(c.uint32()), int(c.uint32()), int(c.uint32())
+	t := &table{
+		names:    c.strings(ncols),
+		sysNames: c.strings(nsys),
+	}
+	t.sysVals = c.floats(nsys)
+	c.pos += (8 - c.pos%8) % 8
+	t.cols = make([][]float64, ncols)
+	for i := range t.cols {
+		t.cols[i] = c.floats(rows)
+	}
+	if c.short || rows == 0 {
+		return nil
+	}
+	return t
+}
+
+// writeCache writes the cache of filename's table t
+func writeCache(filename string, delim rune, t *table) error {
+	st, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	// written beside it and renamed, so a half written cache is never read
+	tmp := cacheName(filename) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := &cacheWriter{w: bufio.NewWriter(f)}
+
+	w.write([]byte(cacheMagic))
+	w.uint32(cacheVersion)
+	w.uint64(uint64(st.Size()))
+	w.uint64(uint64(st.ModTime().UnixNano()))
+	w.uint32(uint32(delim))
+	w.uint32(uint32(len(t.cols[0])))
+	w.uint32(uint32(len(t.cols)))
+	w.uint32(uint32(len(t.sysNames)))
+	w.strings(t.names)
+	w.strings(t.sysNames)
+	w.floats(t.sysVals)
+	w.write(make([]byte, (8-w.n%8)%8))
+	for _, col := range t.cols {
+		w.floats(col)
+	}
+
+	if err := w.w.Flush(); w.err == nil {
+		w.err = err
+	}
+	if err := f.Close(); w.err == nil {
+		w.err = err
+	}
+	if w.err != nil {
+		os.Remove(tmp)
+		return w.err
+	}
+	return os.Rename(tmp, cacheName(filename))
+}
+
+// cacheReader decodes a cache, setting short
+// instead of reading past its end
+type cacheReader struct {
+	b     []byte
+	pos   int
+	short bool
+}
+
+func (c *cacheReader) next(n int) []byte {
+	if n < 0 || c.short || c.pos+n > len(c.b) {
+		c.short = true
+		return nil
+	}
+	c.pos += n
+	return c.b[c.pos-n : c.pos]
+}
+
+func (c *cacheReader) uint32() uint32 {
+	if b := c.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (c *cacheReader) int64() int64 {
+	if b := c.next(8); b != nil {
+		return int64(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (c *cacheReader) strings(n int) []string {
+	s := make([]string, 0, n)
+	for i := 0; i < n && !c.short; i++ {
+		s = append(s, string(c.next(int(c.uint32()))))
+	}
+	return s
+}
+
+// floats is the next n values, in place in the cache when
+// the machine is little endian and they are aligned
+func (c *cacheReader) floats(n int) []float64 {
+	b := c.next(8 * n)
+	if b == nil || n == 0 {
+		return nil
+	}
+	if littleEndian && uintptr(unsafe.Pointer(&b[0]))%8 == 0 {
+		return unsafe.Slice((*float64)(unsafe.Pointer(&b[0])), n)
+	}
+	f := make([]float64, n)
+	for i := range f {
+		f[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return f
+}
+
+// cacheWriter encodes a cache, keeping the first error
+type cacheWriter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+	buf [8]byte
+}
+
+func (w *cacheWriter) write(b []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(b)
+		w.n += len(b)
+	}
+}
+
+func (w *cacheWriter) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(w.buf[:4], v)
+	w.write(w.buf[:4])
+}
+
+func (w *cacheWriter) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(w.buf[:], v)
+	w.write(w.buf[:])
+}
+
+func (w *cacheWriter) strings(s []string) {
+	for _, str := range s {
+		w.uint32(uint32(len(str)))
+		w.write([]byte(str))
+	}
+}
+
+func (w *cacheWriter) floats(f []float64) {
+	for _, v := range f {
+		w.uint64(math.Float64bits(v))
+	}
+}
+
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
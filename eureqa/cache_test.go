@@ -0,0 +1,69 @@
+package eureqa
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.data")
+	const text = "# system: k=2.5\nx,w,t,y\n1,1,0,2\n2,0.5,1,NaN\n3,2,2,6\n"
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	// rewrite changes a cell of the file, keeping its size and mtime
+	rewrite := func(old, new string, mod time.Time) {
+		if err := ioutil.WriteFile(file, []byte(strings.Replace(text, old, new, 1)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(delim rune) *DataSet {
+		d, err := ReadDataSetFile(file, &ReadOptions{Weight: "w", Time: "t", Delim: delim, Cache: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	rewrite("", "", mtime)
+	d := read(0)
+	if _, err := os.Stat(cacheName(file)); err != nil {
+		t.Fatalf("no cache written: %v", err)
+	}
+	c := read(0)
+	if !reflect.DeepEqual(c.VarNames(), d.VarNames()) || !sameFloats(c.Column(0), d.Column(0)) ||
+		!reflect.DeepEqual(c.SysNames(), []string{"k"}) || !reflect.DeepEqual(c.SysVals(0), []float64{2.5}) ||
+		c.Missing().Rows != 1 || c.Length() != 2 {
+		t.Fatalf("read back %v %v %v %v, %d rows", c.VarNames(), c.Column(0), c.SysNames(), c.SysVals(0), c.Length())
+	}
+	for p := 0; p < c.Length(); p++ {
+		if c.Output(p) != d.Output(p) || c.Weight(p) != d.Weight(p) || c.Time(p) != d.Time(p) {
+			t.Errorf("row %d read back as %g %g %g, want %g %g %g",
+				p, c.Output(p), c.Weight(p), c.Time(p), d.Output(p), d.Weight(p), d.Time(p))
+		}
+	}
+
+	// with the same size and mtime the cache is still read
+	rewrite("1,1,0,2", "1,1,0,4", mtime)
+	if y := read(0).Output(0); y != 2 {
+		t.Errorf("cache not read, first output %g", y)
+	}
+	// but not for another delimiter option
+	if y := read(',').Output(0); y != 4 {
+		t.Errorf("cache read for another delimiter, first output %g", y)
+	}
+	// or another mtime
+	rewrite("1,1,0,2", "1,1,0,8", mtime.Add(time.Minute))
+	if y := read(',').Output(0); y != 8 {
+		t.Errorf("cache read for another mtime, first output %g", y)
+	}
+	if readCache(file, ',') == nil || readCache(file, 0) != nil {
+		t.Error("cache not rebuilt for the new mtime and the delimiter option")
+	}
+}
@@ -0,0 +1,38 @@
+package eureqa
+
+import (
+	"context"
+	"testing"
+)
+
+// cancelAt cancels the search when an island reports gen
+type cancelAt struct {
+	NopObserver
+	gen    int
+	cancel context.CancelFunc
+}
+
+func (c *cancelAt) OnGenerationEnd(isle, gen int, stats *GenStats) {
+	if gen == c.gen {
+		c.cancel()
+	}
+}
+
+func TestCancelKeepsFront(t *testing.T) {
+	for _, lockstep := range []bool{true, false} {
+		ctx, cancel := context.WithCancel(context.Background())
+		opts := []Option{WithSeed(3), WithGens(100000), WithIslands(2, 20),
+			WithObserver(&cancelAt{gen: 3, cancel: cancel})}
+		if lockstep {
+			opts = append(opts, WithLockstep())
+		}
+		res, err := NewSearch(quadData(), opts...).Run(ctx)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Cancelled || len(res.Front) == 0 {
+			t.Errorf("lockstep %v: cancelled %v with %d models", lockstep, res.Cancelled, len(res.Front))
+		}
+	}
+}
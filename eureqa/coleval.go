@@ -0,0 +1,159 @@
+package eureqa
+
+import (
+	"math"
+	"sync"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// colEval evaluates an equation a node at a time over every row of a
+// DataSet, reading the inputs from their columns as they are stored,
+// instead of gathering each row for Expr.Eval. Its buffers are reused
+// from one equation to the next through colEvals.
+type colEval struct {
+	data *DataSet
+	free [][]float64 // buffers of data.Length() values
+	x    []float64   // a row, for nodes evaluated a row at a time
+}
+
+var colEvals = sync.Pool{New: func() interface{} { return new(colEval) }}
+
+func getColEval(data *DataSet) *colEval {
+	ce := colEvals.Get().(*colEval)
+	ce.data = data
+	// buffers for data of another length are no use
+	if len(ce.free) > 0 && len(ce.free[0]) != data.Length() {
+		ce.free = ce.free[:0]
+	}
+	if cap(ce.x) < data.Dimensions() {
+		ce.x = make([]float64, data.Dimensions())
+	}
+	ce.x = ce.x[:data.Dimensions()]
+	return ce
+}
+
+func (ce *colEval) release() {
+	ce.data = nil
+	colEvals.Put(ce)
+}
+
+func (ce *colEval) buf() []float64 {
+	if n := len(ce.free); n > 0 {
+		v := ce.free[n-1]
+		ce.free = ce.free[:n-1]
+		return v
+	}
+	return make([]float64, ce.data.Length())
+}
+
+func (ce *colEval) put(v []float64) { ce.free = append(ce.free, v) }
+
+// eval returns the values of e at every row, in a buffer
+// the caller gives back with put. The arithmetic is that
+// of Eval, so the values are the same to the bit.
+func (ce *colEval) eval(e Expr) []float64 {
+	d := ce.data
+	switch n := e.(type) {
+	case *Var:
+		v := ce.buf()
+		copy(v, d.cols[n.P])
+		return v
+	case *ConstantF:
+		v := ce.buf()
+		for p := range v {
+			v[p] = n.F
+		}
+		return v
+	case *System:
+		v := ce.buf()
+		for p := range v {
+			v[p] = d.SysVals(p)[n.P]
+		}
+		return v
+
+	case *Neg:
+		return ce.unary(n.C, func(a float64) float64 { return -a })
+	case *Abs:
+		return ce.unary(n.C, math.Abs)
+	case *Sqrt:
+		return ce.unary(n.C, math.Sqrt)
+	case *Sin:
+		return ce.unary(n.C, math.Sin)
+	case *Cos:
+		return ce.unary(n.C, math.Cos)
+	case *Tan:
+		return ce.unary(n.C, math.Tan)
+	case *Exp:
+		return ce.unary(n.C, math.Exp)
+	case *Log:
+		return ce.unary(n.C, math.Log)
+
+	case *Add:
+		v := ce.buf()
+		for p := range v {
+			v[p] = 0
+		}
+		for _, c := range n.CS {
+			if c == nil {
+				continue // as Eval skips them
+			}
+			cv := ce.eval(c)
+			for p := range v {
+				v[p] += cv[p]
+			}
+			ce.put(cv)
+		}
+		return v
+	case *Mul:
+		v := ce.buf()
+		for p := range v {
+			v[p] = 1
+		}
+		for _, c := range n.CS {
+			if c == nil {
+				continue // as Eval skips them
+			}
+			cv := ce.eval(c)
+			for p := range v {
+				v[p] *= cv[p]
+			}
+			ce.put(cv)
+		}
+		return v
+	case *Div:
+		v, dv := ce.eval(n.Numer), ce.eval(n.Denom)
+		for p := range v {
+			v[p] /= dv[p]
+		}
+		ce.put(dv)
+		return v
+
+	case *UserFunc:
+		prim := primitive(n.T)
+		if prim.Arity == 1 {
+			return ce.unary(n.Args[0], prim.Unary)
+		}
+		v, bv := ce.eval(n.Args[0]), ce.eval(n.Args[1])
+		for p := range v {
+			v[p] = prim.Binary(v[p], bv[p])
+		}
+		ce.put(bv)
+		return v
+	}
+
+	// the other nodes are rare, they get the rows one at a time
+	v := ce.buf()
+	for p := range v {
+		v[p] = e.Eval(0, d.row(p, ce.x), nil, d.SysVals(p))
+	}
+	return v
+}
+
+func (ce *colEval) unary(c Expr, f func(float64) float64) []float64 {
+	v := ce.eval(c)
+	for p := range v {
+		v[p] = f(v[p])
+	}
+	return v
+}
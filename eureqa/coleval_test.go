@@ -0,0 +1,67 @@
+package eureqa
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+func TestEvalEachMatchesEval(t *testing.T) {
+	data := quadData()
+	sum := NewAdd()
+	sum.Insert(NewVar(0))
+	sum.Insert(NewNeg(NewSin(NewVar(1))))
+	sum.Insert(NewTime()) // evaluated a row at a time
+	prod := NewMul()
+	prod.Insert(NewConstantF(1.5))
+	prod.Insert(NewUserFunc(TANH, NewVar(0)))
+	prod.Insert(NewDiv(NewVar(1), NewCos(NewVar(0))))
+
+	for _, e := range []Expr{
+		NewVar(1),
+		sum,
+		prod,
+		NewUserFunc(POW, NewAbs(NewVar(0)), NewConstantF(0.5)),
+		NewExp(NewLog(NewSqrt(NewVar(1)))), // NaN for negative z
+	} {
+		eqn := NewEqn(e, 0)
+		n := 0
+		eqn.EvalEach(data, func(p int, y float64) {
+			want := e.Eval(0, data.Input(p), nil, nil)
+			if y != want && !(math.IsNaN(y) && math.IsNaN(want)) {
+				t.Errorf("%v at row %d: %g, want %g", e, p, y, want)
+			}
+			if r := eqn.EvalRow(data, p); r != y && !(math.IsNaN(y) && math.IsNaN(r)) {
+				t.Errorf("%v at row %d: EvalRow %g, EvalEach %g", e, p, r, y)
+			}
+			n++
+		})
+		if n != data.Length() {
+			t.Errorf("%v evaluated at %d rows of %d", e, n, data.Length())
+		}
+	}
+}
+
+func TestEvalEachNilChildren(t *testing.T) {
+	data := quadData()
+	// crossover and simplification can leave nil children,
+	// which Eval passes over
+	sum := NewAdd()
+	sum.Insert(NewVar(0))
+	sum.Insert(nil)
+	sum.Insert(NewConstantF(2))
+	prod := NewMul()
+	prod.Insert(nil)
+	prod.Insert(NewVar(1))
+	prod.Insert(sum)
+
+	for _, e := range []Expr{sum, prod} {
+		eqn := &Eqn{eqn: e, size: 4}
+		eqn.EvalEach(data, func(p int, y float64) {
+			if want := e.Eval(0, data.Input(p), nil, nil); y != want {
+				t.Errorf("%d children at row %d: %g, want %g", e.NumChildren(), p, y, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,678 @@
+package eureqa
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DataSet is the table of samples a search fits equations to,
+// one output per row of inputs, stored by column
+type DataSet struct {
+	cols   [][]float64 // the inputs, cols[c][p]
+	output []float64
+
+	var_names []string
+	out_name  string
+
+	// the weight and time columns, nil without them
+	weights, times         []float64
+	weight_name, time_name string
+
+	// the experiments, with the values of the system parameters in each,
+	// and the experiment of each row, nil when there is one
+	exp_names []string
+	sys_names []string
+	sys       [][]float64
+	exp       []int
+
+	warnings []error
+
+	missing *MissingReport
+	skip    []bool // rows evaluation skips, nil when there are none
+	imputed bool   // cells were filled from whole column statistics
+}
+
+// NewDataSet makes a DataSet from samples already in memory, input
+// by row, which are copied into columns
+func NewDataSet(input [][]float64, output []float64, varNames []string, outName string) *DataSet {
+	d := &DataSet{output: output, var_names: varNames, out_name: outName}
+	d.cols = make([][]float64, len(varNames))
+	for c := range d.cols {
+		d.cols[c] = make([]float64, len(input))
+		for p, row := range input {
+			d.cols[c][p] = row[c]
+		}
+	}
+	return d
+}
+
+// Length is the number of samples, Dimensions the number of inputs
+func (d *DataSet) Length() int {
+	return len(d.output)
+}
+func (d *DataSet) Dimensions() int {
+	return len(d.cols)
+}
+
+// Input is row p of the inputs, gathered from the columns
+func (d *DataSet) Input(p int) []float64 { return d.row(p, make([]float64, len(d.cols))) }
+func (d *DataSet) Output(p int) float64  { return d.output[p] }
+
+// Column is input c at every row, shared with d
+func (d *DataSet) Column(c int) []float64 { return d.cols[c] }
+
+// row fills buf with row p of the inputs
+func (d *DataSet) row(p int, buf []float64) []float64 {
+	for c, col := range d.cols {
+		buf[c] = col[p]
+	}
+	return buf
+}
+
+// VarNames are the names of the inputs, which equations call X_0, X_1...
+func (d *DataSet) VarNames() []string { return d.var_names }
+func (d *DataSet) OutName() string    { return d.out_name }
+
+// Weight is the row's weight, 1 without a weight column
+func (d *DataSet) Weight(p int) float64 {
+	if d.weights == nil {
+		return 1
+	}
+	return d.weights[p]
+}
+
+// Time is the row's time, its index without a time column
+func (d *DataSet) Time(p int) float64 {
+	if d.times == nil {
+		return float64(p)
+	}
+	return d.times[p]
+}
+
+// ReadOptions say how ReadDataSetFile parses a file
+type ReadOptions struct {
+	// column separator, 0 detects it
+	Delim rune
+
+	// skip bad rows, keeping warnings about them,
+	// instead of failing on the first one
+	Lenient bool
+
+	// what to do with missing values, nil drops their rows
+	Missing *MissingPolicy
+
+	// the output column, the last one when empty
+	Target string
+	// columns which are neither inputs nor output
+	Exclude []string
+	// columns which weight the rows and give their times,
+	// the time column is what missing values interpolate along
+	Weight, Time string
+
+	// a file of weights instead of a weight column, one
+	// on each line for each row of the data file
+	WeightsFile string
+
+	// input columns computed from the others, as "name = formula" with
+	// a formula for ParseFormula, such as "r = sqrt(x^2 + y^2)". They
+	// may use excluded columns and the derived columns before them.
+	Derived []string
+
+	// keep the parsed columns in a binary cache file beside the data
+	// file, name.cache, and map it into memory instead of parsing the
+	// data file again while it is unchanged
+	Cache bool
+}
+
+// DataError is a problem at a place in a data file,
+// Line and Col are 0 when it isn't about one
+type DataError struct {
+	File      string
+	Line, Col int
+	Msg       string
+}
+
+func (e *DataError) Error() string {
+	switch {
+	case e.Col > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// Warnings are the rows a lenient read skipped and why
+func (d *DataSet) Warnings() []error {
+	return d.warnings
+}
+
+// delimiters ReadDataSetFile looks for, anything else is whitespace
+const detectDelims = ",;\t"
+
+// ReadDataSetFile reads a table of samples whose header names the input
+// variables and then the output. Columns are separated by opts.Delim, or
+// when it is 0 by the commas, semicolons or tabs of the header, or
+// whitespace when it has none. Names may be quoted, lines starting with #
+// are comments, except "# system: k=0.5 m=2" which gives the values of
+// the system parameters of the experiment, see ReadExperiments. Values
+// are anything strconv.ParseFloat takes, such as
+// 1.5e-3 and Inf. With semicolons a decimal comma is accepted too. Empty
+// cells, NA, N/A, null, ? and NaN are missing values, which are
+// dealt with by FillMissing with opts.Missing, and the rows with NaNs
+// are counted apart in the report.
+//
+// The last column is the output and the others inputs, unless opts
+// gives the columns other roles or derives more inputs from them.
+//
+// Empty files, duplicate names, ragged rows and unparsable values are
+// errors, unless opts.Lenient when bad rows become warnings instead.
+func ReadDataSetFile(filename string, opts *ReadOptions) (*DataSet, error) {
+	if opts == nil {
+		opts = new(ReadOptions)
+	}
+	t, err := readTable(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names, cols := t.names, t.cols
+	if opts.WeightsFile != "" {
+		if opts.Weight != "" {
+			return nil, &DataError{filename, 0, 0, "both a weight column and a weights file"}
+		}
+		weights, err := readWeights(opts.WeightsFile, len(t.cols[0])+len(t.dropped))
+		if err != nil {
+			return nil, err
+		}
+		o := *opts
+		o.Weight = filepath.Base(opts.WeightsFile)
+		if o.Target == "" {
+			o.Target = names[len(names)-1] // not the weights
+		}
+		opts = &o
+		names = append(names[:len(names):len(names)], o.Weight)
+		cols = append(cols[:len(cols):len(cols)], t.without(weights))
+	}
+
+	d := &DataSet{
+		exp_names: []string{filepath.Base(filename)},
+		sys_names: t.sysNames,
+		sys:       [][]float64{t.sysVals},
+		warnings:  t.warnings,
+	}
+	if err := d.setColumns(cols, names, opts); err != nil {
+		return nil, &DataError{filename, 0, 0, err.Error()}
+	}
+	mp := new(MissingPolicy)
+	if opts.Missing != nil {
+		*mp = *opts.Missing
+	}
+	if mp.TimeCol == "" {
+		mp.TimeCol = opts.Time
+	}
+	if _, err := d.FillMissing(mp); err != nil {
+		return nil, &DataError{filename, 0, 0, err.Error()}
+	}
+	return d, nil
+}
+
+// table is the columns of a data file as read,
+// before ReadOptions gives them their roles
+type table struct {
+	names []string
+	cols  [][]float64 // cols[c][p]
+
+	sysNames []string
+	sysVals  []float64
+
+	// the rows skipped as bad, by their index after the header
+	dropped  []int
+	warnings []error
+}
+
+// without is col, a column of every row of the file,
+// without the rows t dropped
+func (t *table) without(col []float64) []float64 {
+	kept := make([]float64, 0, len(col)-len(t.dropped))
+	d := 0
+	for r, v := range col {
+		if d < len(t.dropped) && t.dropped[d] == r {
+			d++
+			continue
+		}
+		kept = append(kept, v)
+	}
+	return kept
+}
+
+// readTable reads the table of a data file, from its cache when
+// opts.Cache and the cache is up to date, writing the cache when not.
+// Tables with bad rows aren't cached, their warnings would be lost.
+func readTable(filename string, opts *ReadOptions) (*table, error) {
+	if opts.Cache {
+		if t := readCache(filename, opts.Delim); t != nil {
+			return t, nil
+		}
+	}
+	t, err := parseTable(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Cache && len(t.dropped) == 0 {
+		// only a speedup, so failing to write it isn't an error
+		writeCache(filename, opts.Delim, t)
+	}
+	return t, nil
+}
+
+// parseTable parses the text of a data file into columns
+func parseTable(filename string, opts *ReadOptions) (*table, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	t := new(table)
+	if t.sysNames, t.sysVals, err = readSystem(filename, data); err != nil {
+		return nil, err
+	}
+	rows, delim, err := readRows(data, opts.Delim)
+	if err != nil {
+		return nil, &DataError{filename, 0, 0, err.Error()}
+	}
+	if len(rows) == 0 {
+		return nil, &DataError{filename, 0, 0, "no header, the file is empty"}
+	}
+
+	header := rows[0]
+	if header.err != nil {
+		return nil, header.dataError(filename)
+	}
+	t.names = header.fields
+	if len(t.names) < 2 {
+		return nil, &DataError{filename, header.line, 0, "the header needs input columns and an output column"}
+	}
+	for c, name := range t.names {
+		for _, prev := range t.names[:c] {
+			if name == prev {
+				return nil, &DataError{filename, header.line, header.cols[c], fmt.Sprintf("duplicate column name %q", name)}
+			}
+		}
+	}
+
+	t.cols = make([][]float64, len(t.names))
+	for c := range t.cols {
+		t.cols[c] = make([]float64, 0, len(rows)-1)
+	}
+	for r, row := range rows[1:] {
+		vals, rerr := row.values(filename, len(t.names), delim)
+		if rerr != nil {
+			if !opts.Lenient {
+				return nil, rerr
+			}
+			t.warnings = append(t.warnings, rerr)
+			t.dropped = append(t.dropped, r)
+			continue
+		}
+		for c, v := range vals {
+			t.cols[c] = append(t.cols[c], v)
+		}
+	}
+	if len(t.cols[0]) == 0 {
+		return nil, &DataError{filename, 0, 0, "no samples after the header"}
+	}
+	return t, nil
+}
+
+// setColumns adds the derived columns to the table's columns
+// and sorts them into d by the roles in opts
+func (d *DataSet) setColumns(cols [][]float64, names []string, opts *ReadOptions) error {
+	target := names[len(names)-1]
+	if opts.Target != "" {
+		target = opts.Target
+	}
+
+	for _, def := range opts.Derived {
+		name, e, err := ParseDerived(def, names)
+		if err != nil {
+			return err
+		}
+		for _, prev := range names {
+			if name == prev {
+				return fmt.Errorf("derived column %s is already a column", name)
+			}
+		}
+		col, row := make([]float64, len(cols[0])), make([]float64, len(cols))
+		for p := range col {
+			for c := range cols {
+				row[c] = cols[c][p]
+			}
+			col[p] = e.Eval(0, row, nil, nil)
+		}
+		cols = append(cols[:len(cols):len(cols)], col)
+		names = append(names[:len(names):len(names)], name)
+	}
+
+	role := make(map[string]string)
+	for _, name := range opts.Exclude {
+		role[name] = "exclude"
+	}
+	for _, r := range [][2]string{{opts.Weight, "weight"}, {opts.Time, "time"}, {target, "target"}} {
+		if r[0] == "" {
+			continue
+		}
+		if role[r[0]] != "" {
+			return fmt.Errorf("column %s can't be both %s and %s", r[0], role[r[0]], r[1])
+		}
+		role[r[0]] = r[1]
+	}
+	for name, r := range role {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			return fmt.Errorf("unknown %s column %q", r, name)
+		}
+	}
+
+	// the columns are shared with the table, not copied
+	for c, name := range names {
+		switch role[name] {
+		case "":
+			d.cols = append(d.cols, cols[c])
+			d.var_names = append(d.var_names, name)
+		case "target":
+			d.output, d.out_name = cols[c], name
+		case "weight":
+			d.weights, d.weight_name = cols[c], name
+		case "time":
+			d.times, d.time_name = cols[c], name
+		}
+	}
+	if len(d.cols) == 0 {
+		return fmt.Errorf("no input columns left")
+	}
+	sum := 0.0
+	for _, w := range d.weights {
+		if w < 0 || math.IsInf(w, 0) {
+			return fmt.Errorf("weight %g in %s, weights must be finite and not negative", w, d.weight_name)
+		}
+		if !math.IsNaN(w) {
+			sum += w
+		}
+	}
+	// else every weighted error is 0/0
+	if d.weights != nil && !(sum > 0) {
+		return fmt.Errorf("the weights in %s sum to %g, some must be positive", d.weight_name, sum)
+	}
+	return nil
+}
+
+// readSystem reads the "# system: name=value ..." comment lines,
+// whose pairs may be separated by spaces or commas
+func readSystem(filename string, data []byte) (names []string, vals []float64, err error) {
+	for l, line := range bytes.Split(data, []byte{'\n'}) {
+		text := strings.TrimSpace(string(line))
+		if !strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(text[1:])
+		if !strings.HasPrefix(text, "system:") {
+			continue
+		}
+		for _, pair := range strings.FieldsFunc(text[len("system:"):], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			eq := strings.Index(pair, "=")
+			if eq <= 0 {
+				return nil, nil, &DataError{filename, l + 1, 0, fmt.Sprintf("system parameter %q isn't name=value", pair)}
+			}
+			val, perr := strconv.ParseFloat(pair[eq+1:], 64)
+			if perr != nil {
+				return nil, nil, &DataError{filename, l + 1, 0, fmt.Sprintf("can't parse system parameter %q", pair)}
+			}
+			for _, prev := range names {
+				if prev == pair[:eq] {
+					return nil, nil, &DataError{filename, l + 1, 0, fmt.Sprintf("duplicate system parameter %q", prev)}
+				}
+			}
+			names = append(names, pair[:eq])
+			vals = append(vals, val)
+		}
+	}
+	return names, vals, nil
+}
+
+// readWeights reads a weights file, one weight on each of n lines
+// besides blank ones and comments, for ReadOptions.WeightsFile
+func readWeights(filename string, n int) ([]float64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rows, _, err := readRows(data, ' ')
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != n {
+		return nil, &DataError{filename, 0, 0, fmt.Sprintf("%d weights for %d rows", len(rows), n)}
+	}
+	weights := make([]float64, n)
+	for r, row := range rows {
+		vals, err := row.values(filename, 1, ' ')
+		if err != nil {
+			return nil, err
+		}
+		if w := vals[0]; w < 0 || math.IsInf(w, 0) {
+			return nil, &DataError{filename, row.line, row.cols[0], "weights must be finite and not negative"}
+		}
+		weights[r] = vals[0]
+	}
+	return weights, nil
+}
+
+// dataRow is a row's fields, with the line
+// and the columns the fields start at
+type dataRow struct {
+	line   int
+	fields []string
+	cols   []int
+
+	err *csv.ParseError // the row couldn't be split into fields
+}
+
+// dataError is about the line the bad row starts on, the
+// column only when the parser gave up on that line too
+func (row *dataRow) dataError(filename string) *DataError {
+	if row.err.Line != row.err.StartLine {
+		return &DataError{filename, row.err.StartLine, 0, "quote not closed on the line"}
+	}
+	return &DataError{filename, row.err.Line, row.err.Column, row.err.Err.Error()}
+}
+
+// values parses the row, which must have ncols fields
+func (row *dataRow) values(filename string, ncols int, delim rune) ([]float64, error) {
+	if row.err != nil {
+		return nil, row.dataError(filename)
+	}
+	if len(row.fields) != ncols {
+		return nil, &DataError{filename, row.line, 0, fmt.Sprintf("%d values for %d columns", len(row.fields), ncols)}
+	}
+	vals := make([]float64, ncols)
+	for c, cell := range row.fields {
+		val, err := parseValue(cell, delim)
+		if err != nil {
+			return nil, &DataError{filename, row.line, row.cols[c], fmt.Sprintf("can't parse %q as a number", cell)}
+		}
+		vals[c] = val
+	}
+	return vals, nil
+}
+
+// readRows splits data into rows of fields, without the
+// comments, and returns the delimiter it used. Rows with
+// bad quotes hold the error, for lenient reads to skip.
+func readRows(data []byte, delim rune) ([]*dataRow, rune, error) {
+	lines := bytes.Split(data, []byte{'\n'})
+	for l, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte{'#'}) {
+			lines[l] = nil // blank lines are skipped
+		}
+	}
+
+	if delim == 0 {
+		delim = detectDelim(lines)
+	}
+	var rows []*dataRow
+	if delim == ' ' {
+		for l, line := range lines {
+			row, err := splitSpace(line, l+1)
+			if err != nil {
+				row = &dataRow{line: l + 1, err: err}
+			}
+			if len(row.fields) > 0 || row.err != nil {
+				rows = append(rows, row)
+			}
+		}
+		return rows, delim, nil
+	}
+
+	// off is the lines before those the reader reads
+	for off := 0; ; {
+		r := csv.NewReader(bytes.NewReader(bytes.Join(lines[off:], []byte{'\n'})))
+		r.Comma = delim
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		for {
+			fields, err := r.Read()
+			if err == io.EOF {
+				return rows, delim, nil
+			}
+			if perr, ok := err.(*csv.ParseError); ok {
+				perr.StartLine += off
+				perr.Line += off
+				rows = append(rows, &dataRow{line: perr.StartLine, err: perr})
+				// a quote left open would take the rest of the file as
+				// one field, so read on from the line after the bad row
+				off = perr.StartLine
+				break
+			}
+			if err != nil {
+				return nil, delim, err
+			}
+			row := &dataRow{fields: fields, cols: make([]int, len(fields))}
+			for f := range fields {
+				row.line, row.cols[f] = r.FieldPos(f)
+				row.line += off
+			}
+			rows = append(rows, row)
+		}
+	}
+}
+
+// splitSpace splits line l at runs of whitespace. A field starting with
+// a quote runs to the closing one, with "" for a quote inside it, so
+// names may hold spaces like they may hold the delimiter in csv files.
+func splitSpace(line []byte, l int) (*dataRow, *csv.ParseError) {
+	row := &dataRow{line: l}
+	for c := 0; c < len(line); {
+		for c < len(line) && isSpace(line[c]) {
+			c++
+		}
+		if c == len(line) {
+			break
+		}
+		start := c
+		var field []byte
+		if line[c] == '"' {
+			for c++; ; c++ {
+				if c == len(line) {
+					return nil, &csv.ParseError{StartLine: l, Line: l, Column: start + 1, Err: csv.ErrQuote}
+				}
+				if line[c] == '"' {
+					if c+1 == len(line) || line[c+1] != '"' {
+						c++
+						break
+					}
+					c++
+				}
+				field = append(field, line[c])
+			}
+			if c < len(line) && !isSpace(line[c]) {
+				return nil, &csv.ParseError{StartLine: l, Line: l, Column: c + 1, Err: csv.ErrQuote}
+			}
+		} else {
+			for c < len(line) && !isSpace(line[c]) {
+				c++
+			}
+			field = line[start:c]
+		}
+		row.fields = append(row.fields, string(field))
+		row.cols = append(row.cols, start+1)
+	}
+	return row, nil
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r'
+}
+
+// detectDelim picks the delimiter found most often outside quotes in the
+// header, the first line which isn't blank or a comment, and as often
+// in the first row after it, so names like f(x,y) aren't mistaken
+func detectDelim(lines [][]byte) rune {
+	var counts []map[rune]int
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		count := make(map[rune]int)
+		quoted := false
+		for _, r := range string(line) {
+			if r == '"' {
+				quoted = !quoted
+			} else if !quoted && strings.ContainsRune(detectDelims, r) {
+				count[r]++
+			}
+		}
+		if counts = append(counts, count); len(counts) == 2 {
+			break
+		}
+	}
+	if len(counts) == 0 {
+		return ' '
+	}
+
+	best := ' '
+	for _, r := range detectDelims {
+		if len(counts) == 2 && counts[1][r] != counts[0][r] {
+			continue
+		}
+		if counts[0][r] > counts[0][best] {
+			best = r
+		}
+	}
+	return best
+}
+
+func parseValue(cell string, delim rune) (float64, error) {
+	if isMissingToken(cell) {
+		return math.NaN(), nil
+	}
+	cell = strings.TrimSpace(cell)
+	if strings.EqualFold(cell, "nan") {
+		return nanValue, nil
+	}
+	if delim == ';' && !strings.Contains(cell, ".") {
+		cell = strings.Replace(cell, ",", ".", 1)
+	}
+	return strconv.ParseFloat(cell, 64)
+}
@@ -0,0 +1,141 @@
+package eureqa
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readString reads text as a data file
+func readString(t *testing.T, text string, opts *ReadOptions) (*DataSet, error) {
+	file := filepath.Join(t.TempDir(), "test.data")
+	if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ReadDataSetFile(file, opts)
+}
+
+func TestReadDelims(t *testing.T) {
+	tests := []struct {
+		name, text string
+		names      []string
+		out        string
+	}{
+		{"space", "x  y  f(x,y)\n1  2  3\n4.5\t-5 6e1\n", []string{"x", "y"}, "f(x,y)"},
+		{"comma", "x,y,f(x;y)\n1,2,3\n4.5, -5, 6e1\n", []string{"x", "y"}, "f(x;y)"},
+		{"semicolon", "x;y;f(x,y)\n1;2;3\n4,5;-5;6e1\n", []string{"x", "y"}, "f(x,y)"},
+		{"tab", "x\ty\tf(x, y)\n1\t2\t3\n4.5\t-5\t6e1\n", []string{"x", "y"}, "f(x, y)"},
+		{"comment", "# made by hand\nx y out\n\n1 2 3\n# between\n4.5 -5 6e1\n", []string{"x", "y"}, "out"},
+
+		{"quoted space", "\"in put\"  \"y\"  \"the \"\"out\"\"\"\n1 2 3\n4.5 -5 6e1\n", []string{"in put", "y"}, "the \"out\""},
+		{"quoted comma", "\"x, m\",y,\"f(x,y)\"\n1,2,3\n4.5,-5,6e1\n", []string{"x, m", "y"}, "f(x,y)"},
+		{"quoted semicolon", "\"x;1\";\"y 2\";out\n1;2;3\n4,5;-5;6e1\n", []string{"x;1", "y 2"}, "out"},
+	}
+	for _, test := range tests {
+		d, err := readString(t, test.text, nil)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(d.VarNames(), test.names) || d.OutName() != test.out {
+			t.Errorf("%s: names %q %q, want %q %q", test.name, d.VarNames(), d.OutName(), test.names, test.out)
+		}
+		want := [][]float64{{1, 4.5}, {2, -5}}
+		if d.Length() != 2 || !reflect.DeepEqual(d.Column(0), want[0]) || !reflect.DeepEqual(d.Column(1), want[1]) ||
+			d.Output(0) != 3 || d.Output(1) != 60 {
+			t.Errorf("%s: read %v %v, %v %v", test.name, d.Column(0), d.Column(1), d.Output(0), d.Output(1))
+		}
+	}
+}
+
+func TestReadBadQuotes(t *testing.T) {
+	for _, text := range []string{
+		"\"x  y\n1 2\n",
+		"\"x\"y  out\n1 2\n",
+		"x,\"y\n1,2\n",
+	} {
+		if _, err := readString(t, text, nil); err == nil {
+			t.Errorf("no error reading %q", text)
+		} else if _, ok := err.(*DataError); !ok {
+			t.Errorf("%q: %T isn't a DataError", text, err)
+		}
+	}
+}
+
+func TestReadDataFiles(t *testing.T) {
+	tests := []struct {
+		file  string
+		names []string
+		out   string
+	}{
+		{"F1.data", []string{"x"}, "f(x)"},
+		{"F2.data", []string{"x"}, "f(x)"},
+		{"F3.data", []string{"x", "y"}, "f(x,y)"},
+		{"F4.data", []string{"x", "y", "z"}, "f(x,y,z)"},
+	}
+	for _, test := range tests {
+		d, err := ReadDataSetFile(filepath.Join("..", "data", test.file), nil)
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(d.VarNames(), test.names) || d.OutName() != test.out {
+			t.Errorf("%s: names %q %q, want %q %q", test.file, d.VarNames(), d.OutName(), test.names, test.out)
+		}
+		if d.Length() == 0 || len(d.Warnings()) > 0 {
+			t.Errorf("%s: %d rows, warnings %v", test.file, d.Length(), d.Warnings())
+		}
+	}
+}
+
+func TestReadLenientQuotes(t *testing.T) {
+	for _, text := range []string{
+		"x,y,out\n1,2,3\n4,5\"x,6\n7,8,9\n",
+		"x  y  out\n1 2 3\n4 \"5 6\n7 8 9\n",
+		// the quote is never closed
+		"x,y,out\n1,2,3\n\"4,5,6\n7,8,9\n",
+		"x;y;out\n1;2;3\n4;\"5;6\n\n# note\n7;8;9\n",
+	} {
+		if _, err := readString(t, text, nil); err == nil {
+			t.Errorf("no error reading %q strictly", text)
+		}
+		d, err := readString(t, text, &ReadOptions{Lenient: true})
+		if err != nil {
+			t.Errorf("%q: %v", text, err)
+			continue
+		}
+		if d.Length() != 2 || d.Output(1) != 9 || len(d.Warnings()) != 1 {
+			t.Errorf("%q: %d rows, warnings %v", text, d.Length(), d.Warnings())
+		} else if derr, ok := d.Warnings()[0].(*DataError); !ok || derr.Line != 3 {
+			t.Errorf("%q: warning %v isn't about line 3", text, d.Warnings()[0])
+		}
+	}
+}
+
+func TestReadZeroWeights(t *testing.T) {
+	for _, text := range []string{
+		"x  w  y\n1 0 2\n3 0 4\n",
+		"x  w  y\n1 0 2\n3 NaN 4\n",
+	} {
+		_, err := readString(t, text, &ReadOptions{Weight: "w", Missing: &MissingPolicy{Default: MISS_SKIP}})
+		if _, ok := err.(*DataError); !ok {
+			t.Errorf("%q: error %v, want a DataError", text, err)
+		}
+	}
+	d, err := readString(t, "x  w  y\n1 0 2\n3 0.5 4\n", &ReadOptions{Weight: "w"})
+	if err != nil || d.Weight(1) != 0.5 {
+		t.Errorf("weights 0 and 0.5: %v", err)
+	}
+}
+
+func TestReadNaNRows(t *testing.T) {
+	d, err := readString(t, "x y\n1 2\nNaN 3\nNA 4\nnan ?\n5 6\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := d.Missing()
+	if d.Length() != 2 || mr.Rows != 3 || mr.NaNRows != 2 || mr.Dropped != 3 {
+		t.Errorf("%d rows, missing %+v", d.Length(), mr)
+	}
+}
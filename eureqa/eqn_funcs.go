@@ -1,7 +1,6 @@
-package main
+package eureqa
 
 import (
-	"math"
 	"math/rand"
 	"sort"
 
@@ -79,6 +78,8 @@ func exprGrow(e ExprType, egfunc ExprGenFunc, egp *TreeParams, rng *rand.Rand) E
 	case e == VAR:
 		p := egp.UsableVars[rng.Intn(len(egp.UsableVars))]
 		return NewVar(p)
+	case e == SYSTEM:
+		return NewSystem(rng.Intn(egp.NumSys))
 	case e == CONSTANTF:
 		return NewConstantF(rng.NormFloat64() * 2.0)
 
@@ -131,6 +132,19 @@ func exprGrow(e ExprType, egfunc ExprGenFunc, egp *TreeParams, rng *rand.Rand) E
 	case e == DIV:
 		egp.CurrDepth++
 		return NewDiv(egfunc(egp, rng), egfunc(egp, rng))
+
+	case e >= USER_PRIM:
+		egp.CurrDepth++
+		p := primitive(e)
+		egp.InTrig = egp.InTrig || p.Trig
+		args := make([]Expr, p.Arity)
+		for i := range args {
+			args[i] = egfunc(egp, rng)
+		}
+		if p.Trig {
+			egp.InTrig = false
+		}
+		return NewUserFunc(e, args...)
 	}
 	return NewNull()
 }
@@ -175,7 +189,7 @@ func InjectEqn_Vanilla(p1 Expr, egp *TreeParams, rng *rand.Rand) Expr {
 	e2 := eqn.GetExpr(&s2)
 
 	egp.CurrSize = eqn.Size() - e2.Size()
-	egp.CurrDepth = e2.Depth()
+	egp.CurrDepth = depthAt(eqn, s1)
 	egp.ResetTemp()
 
 	// not correct (should be size based)
@@ -204,6 +218,9 @@ func MutateEqn_Vanilla(eqn Expr, egp *TreeParams, rng *rand.Rand) {
 			p := egp.UsableVars[rng.Intn(len(egp.UsableVars))]
 			e2.(*Var).P = p
 			mut = true
+		case SYSTEM:
+			e2.(*System).P = rng.Intn(egp.NumSys)
+			mut = true
 		case ADD:
 
 		case MUL:
@@ -356,7 +373,16 @@ func lessSizeError(l, r *Eqn) bool {
 	return l.eqn.AmILess(r.eqn)
 }
 
+// ParetoSort orders the queue front by front on size and error,
+// or on size and every objective when there is more than one
 func (bb *EqnQueue) ParetoSort() {
+	for _, e := range bb.queue {
+		if e != nil && len(e.objs) > 1 {
+			bb.paretoSortObjs()
+			return
+		}
+	}
+
 	bb.less = lessSizeError
 	sort.Sort(bb)
 
@@ -411,6 +437,44 @@ func (bb *EqnQueue) ParetoSort() {
 	}
 }
 
+// paretoSortObjs peels off the equations no other dominates, in order
+// of size and error, until the queue is sorted with the nils last
+func (bb *EqnQueue) paretoSortObjs() {
+	rest := make([]*Eqn, 0, len(bb.queue))
+	for _, e := range bb.queue {
+		if e != nil {
+			rest = append(rest, e)
+		}
+	}
+
+	sorted := make([]*Eqn, 0, len(bb.queue))
+	for len(rest) > 0 {
+		var front, next []*Eqn
+		for i, e := range rest {
+			dominated := false
+			for j, f := range rest {
+				if i != j && dominates(f, e) {
+					dominated = true
+					break
+				}
+			}
+			if dominated {
+				next = append(next, e)
+			} else {
+				front = append(front, e)
+			}
+		}
+		sort.Stable(EqnSizeArray(front))
+		sorted = append(sorted, front...)
+		rest = next
+	}
+
+	for i := range bb.queue {
+		bb.queue[i] = nil
+	}
+	copy(bb.queue, sorted)
+}
+
 func (tp *TreeParams) CheckExpr(e Expr) bool {
 	if e.Size() < tp.MinSize {
 		//    fmt.Printf( "Too SMALL:  e:%v  l:%v\n", e.Size(), tp.TmpMinSize )
@@ -466,10 +530,15 @@ func (t *TreeParams) Clone() *TreeParams {
 }
 
 func badEqnFilter(eqn *Eqn) bool {
-	if eqn.err > 1e9 ||
-		math.IsInf(eqn.err, 0) ||
-		math.IsNaN(eqn.err) {
-		return true
+	return !validErr(eqn.err)
+}
+
+// withSystem adds SYSTEM to the leaf types, once
+func withSystem(leafs []ExprType) []ExprType {
+	for _, t := range leafs {
+		if t == SYSTEM {
+			return leafs
+		}
 	}
-	return false
+	return append(leafs, SYSTEM)
 }
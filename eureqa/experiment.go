@@ -0,0 +1,368 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// Each data file is an experiment, whose "# system:" line gives the values
+// of the system parameters it was run with. Equations can use them as
+// S_0, S_1... leaves, so one equation models every experiment, and
+// Result.FitExperiments finds the constants which fit each best.
+
+// ReadExperiments reads data files with ReadDataSetFile and merges them,
+// they must have the same columns and system parameters
+func ReadExperiments(filenames []string, opts *ReadOptions) (*DataSet, error) {
+	if opts != nil && opts.WeightsFile != "" && len(filenames) > 1 {
+		return nil, fmt.Errorf("a weights file is for one data file, not %d", len(filenames))
+	}
+	sets := make([]*DataSet, len(filenames))
+	for i, fn := range filenames {
+		d, err := ReadDataSetFile(fn, opts)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = d
+	}
+	return MergeExperiments(sets...)
+}
+
+// MergeExperiments makes one DataSet of experiments with the same columns
+// and system parameters, whose rows remember the experiment they are from
+func MergeExperiments(sets ...*DataSet) (*DataSet, error) {
+	if len(sets) == 1 {
+		return sets[0], nil
+	}
+	first := sets[0]
+	m := first.emptyLike()
+	// the sums start from nothing, not the first's
+	m.exp_names, m.sys, m.missing = nil, nil, nil
+	m.cols = make([][]float64, len(first.cols))
+	for _, d := range sets {
+		if err := first.SameColumns(d); err != nil {
+			return nil, err
+		}
+		if (d.weights == nil) != (first.weights == nil) || (d.times == nil) != (first.times == nil) {
+			return nil, fmt.Errorf("experiments %s and %s differ in weight or time columns", first.exp_names[0], d.exp_names[0])
+		}
+
+		for i := 0; i < d.Experiments(); i++ {
+			sys, err := first.sysOrder(d, i)
+			if err != nil {
+				return nil, err
+			}
+			m.exp_names = append(m.exp_names, d.ExperimentName(i))
+			m.sys = append(m.sys, sys)
+		}
+		base := len(m.exp_names) - d.Experiments()
+		for p := range d.output {
+			for c, col := range d.cols {
+				m.cols[c] = append(m.cols[c], col[p])
+			}
+			m.output = append(m.output, d.output[p])
+			m.exp = append(m.exp, base+d.Experiment(p))
+			if d.weights != nil {
+				m.weights = append(m.weights, d.weights[p])
+			}
+			if d.times != nil {
+				m.times = append(m.times, d.times[p])
+			}
+			if d.skip != nil && m.skip == nil {
+				m.skip = make([]bool, len(m.output)-1, len(m.output))
+			}
+			if m.skip != nil {
+				m.skip = append(m.skip, d.Skipped(p))
+			}
+		}
+		m.warnings = append(m.warnings, d.warnings...)
+		m.missing = m.missing.add(d.missing)
+		m.imputed = m.imputed || d.imputed
+	}
+	return m, nil
+}
+
+// sysOrder is the system values of experiment i of d
+// in the order of the system parameters of first
+func (first *DataSet) sysOrder(d *DataSet, i int) ([]float64, error) {
+	if len(d.sys_names) != len(first.sys_names) {
+		return nil, fmt.Errorf("experiment %s has system parameters %v, not %v", d.ExperimentName(i), d.sys_names, first.sys_names)
+	}
+	sys := make([]float64, len(first.sys_names))
+	for s, name := range first.sys_names {
+		found := false
+		for t, dn := range d.sys_names {
+			if dn == name {
+				sys[s], found = d.sys[i][t], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("experiment %s has system parameters %v, not %v", d.ExperimentName(i), d.sys_names, first.sys_names)
+		}
+	}
+	return sys, nil
+}
+
+// add sums two reports on the same columns, either may be nil
+func (mr *MissingReport) add(o *MissingReport) *MissingReport {
+	if mr == nil || o == nil {
+		if mr == nil {
+			return o
+		}
+		return mr
+	}
+	sum := &MissingReport{Names: mr.Names, Cells: make([]int, len(mr.Cells))}
+	for c := range sum.Cells {
+		sum.Cells[c] = mr.Cells[c] + o.Cells[c]
+	}
+	sum.Rows = mr.Rows + o.Rows
+	sum.NaNRows = mr.NaNRows + o.NaNRows
+	sum.Dropped = mr.Dropped + o.Dropped
+	sum.Skipped = mr.Skipped + o.Skipped
+	return sum
+}
+
+// Experiments is the number of experiments, at least 1
+func (d *DataSet) Experiments() int {
+	if len(d.exp_names) == 0 {
+		return 1
+	}
+	return len(d.exp_names)
+}
+
+func (d *DataSet) ExperimentName(i int) string {
+	if i >= len(d.exp_names) {
+		return fmt.Sprintf("exp%d", i)
+	}
+	return d.exp_names[i]
+}
+
+// Experiment is the experiment row p is from
+func (d *DataSet) Experiment(p int) int {
+	if d.exp == nil {
+		return 0
+	}
+	return d.exp[p]
+}
+
+// SysNames are the system parameters, which equations call S_0, S_1...
+func (d *DataSet) SysNames() []string { return d.sys_names }
+
+// SysVals are the system values of the experiment of row p
+func (d *DataSet) SysVals(p int) []float64 {
+	if len(d.sys) == 0 {
+		return nil
+	}
+	return d.sys[d.Experiment(p)]
+}
+
+// experiment is the rows of experiment i
+func (d *DataSet) experiment(i int) *DataSet {
+	held := make([]bool, d.Length())
+	for p := range held {
+		held[p] = d.Experiment(p) == i
+	}
+	_, rows := d.split(held)
+	return rows
+}
+
+// EvalRow evaluates the equation at row p of data, with the
+// system values of its experiment, see EvalEach for every row
+func (e *Eqn) EvalRow(data *DataSet, p int) float64 {
+	ce := getColEval(data)
+	defer ce.release()
+	return e.eqn.Eval(0, data.row(p, ce.x), nil, data.SysVals(p))
+}
+
+// ExperimentFit is a model's constants fitted to one experiment
+type ExperimentFit struct {
+	Name   string
+	Consts []float64 // the values of C_0, C_1... of the Shared form
+	Err    float64   // the first objective with them
+
+	// whether the simplex search closed within FitTolerance, rather
+	// than running out of steps with the constants still moving
+	Converged bool
+}
+
+// FitTolerance is how close the simplex search's points, or their fits,
+// must come, relative to the best, for FitExperiments to stop
+const FitTolerance = 1e-12
+
+// FitExperiments refits the constants of each model of the front to each
+// experiment of data on its own, with f, the search's Fitness or MAE when
+// nil, setting the models' Shared form and Experiments.
+func (r *Result) FitExperiments(data *DataSet, f Fitness) {
+	if f == nil {
+		f = MAE{}
+	}
+	exps := make([]*DataSet, data.Experiments())
+	for i := range exps {
+		exps[i] = data.experiment(i)
+	}
+	for _, m := range r.Front {
+		var consts []*ConstantF
+		eqn := m.eqn.Clone()
+		m.Shared, consts = sharedForm(eqn)
+		fitted := &Eqn{eqn: eqn, size: m.size}
+
+		m.Experiments = make([]*ExperimentFit, len(exps))
+		for i, rows := range exps {
+			score := func(c []float64) float64 {
+				for k, cf := range consts {
+					cf.F = c[k]
+				}
+				objs, ok := f.Objectives(fitted, rows)
+				if !ok || len(objs) == 0 {
+					return math.Inf(1)
+				}
+				return objs[0]
+			}
+			start := make([]float64, len(consts))
+			for k := range start {
+				start[k] = m.Shared.consts[k]
+			}
+			best, err, conv := minimize(score, start, 200*(len(start)+1), FitTolerance)
+			m.Experiments[i] = &ExperimentFit{Name: data.ExperimentName(i), Consts: best, Err: err, Converged: conv}
+		}
+	}
+	r.fitted = true
+}
+
+// SharedForm is an equation with its constants made coefficients, C_0,
+// C_1... in preorder, which each experiment gives values of its own
+type SharedForm struct {
+	Expr   Expr
+	consts []float64 // the values found by the search
+}
+
+func (sf *SharedForm) String() string { return sf.Expr.String() }
+
+// sharedForm makes the shared form of eqn, and
+// returns the constants of eqn in the same order
+func sharedForm(eqn Expr) (*SharedForm, []*ConstantF) {
+	eqn.CalcExprStats()
+	form := eqn.Clone()
+	form.CalcExprStats()
+
+	var consts []*ConstantF
+	var positions []int
+	for pos := 0; pos < eqn.Size(); pos++ {
+		at := pos
+		if c, ok := eqn.GetExpr(&at).(*ConstantF); ok {
+			consts = append(consts, c)
+			positions = append(positions, pos)
+		}
+	}
+
+	sf := &SharedForm{consts: make([]float64, len(consts))}
+	// from the last back, so the positions before stay put
+	for k := len(positions) - 1; k >= 0; k-- {
+		sf.consts[k] = consts[k].F
+		if positions[k] == 0 {
+			form = NewConstant(k)
+		} else {
+			SwapExpr(form, NewConstant(k), positions[k])
+		}
+	}
+	form.CalcExprStats()
+	sf.Expr = form
+	return sf, consts
+}
+
+// minimize is a Nelder-Mead simplex search for the x near start
+// where f is least, within iters steps, returning x, f(x) and whether
+// the simplex closed, its values or its points coming within tol
+// of each other, relative to the least
+func minimize(f func(x []float64) float64, start []float64, iters int, tol float64) ([]float64, float64, bool) {
+	n := len(start)
+	if n == 0 {
+		return start, f(start), true
+	}
+
+	type vertex struct {
+		x []float64
+		f float64
+	}
+	simplex := make([]vertex, n+1)
+	for i := range simplex {
+		x := append([]float64{}, start...)
+		if i > 0 {
+			// step each constant by a tenth, or a little when it is 0
+			if x[i-1] != 0 {
+				x[i-1] *= 1.1
+			} else {
+				x[i-1] = 0.1
+			}
+		}
+		simplex[i] = vertex{x, f(x)}
+	}
+	// points along the line from the centroid through the worst vertex
+	along := func(c []float64, w []float64, t float64) vertex {
+		x := make([]float64, n)
+		for k := range x {
+			x[k] = c[k] + t*(w[k]-c[k])
+		}
+		return vertex{x, f(x)}
+	}
+
+	// whether the points are all within tol of the best, relative to
+	// its size, which is how the search ends at an exact fit, where
+	// the values can't come within tol of 0
+	collapsed := func() bool {
+		for _, v := range simplex[1:] {
+			for k, x := range v.x {
+				if math.Abs(x-simplex[0].x[k]) > tol*(math.Abs(simplex[0].x[k])+1) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	converged := false
+	for it := 0; it < iters; it++ {
+		sort.SliceStable(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+		best, worst := simplex[0], simplex[n]
+		if math.Abs(worst.f-best.f) <= tol*(math.Abs(best.f)+tol) || collapsed() {
+			converged = true
+			break
+		}
+
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for k := range centroid {
+				centroid[k] += v.x[k] / float64(n)
+			}
+		}
+
+		refl := along(centroid, worst.x, -1)
+		switch {
+		case refl.f < best.f:
+			if exp := along(centroid, worst.x, -2); exp.f < refl.f {
+				simplex[n] = exp
+			} else {
+				simplex[n] = refl
+			}
+		case refl.f < simplex[n-1].f:
+			simplex[n] = refl
+		default:
+			if con := along(centroid, worst.x, 0.5); con.f < worst.f {
+				simplex[n] = con
+				continue
+			}
+			// shrink toward the best
+			for i := 1; i <= n; i++ {
+				x := make([]float64, n)
+				for k := range x {
+					x[k] = best.x[k] + 0.5*(simplex[i].x[k]-best.x[k])
+				}
+				simplex[i] = vertex{x, f(x)}
+			}
+		}
+	}
+	sort.SliceStable(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+	return simplex[0].x, simplex[0].f, converged
+}
@@ -0,0 +1,101 @@
+package eureqa
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+func TestMergeMissing(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.data"), filepath.Join(dir, "b.data")}
+	texts := []string{
+		"# system: k=1\nx  y\n1 2\nNA 3\n4 5\n",
+		"# system: k=2\nx  y\n1 3\n2 NA\n4 6\nNA 7\n",
+	}
+	for i, text := range texts {
+		if err := ioutil.WriteFile(files[i], []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := ReadExperiments(files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := d.Missing()
+	if d.Length() != 4 || mr.Rows != 3 || mr.Dropped != 3 || mr.Cells[0] != 2 || mr.Cells[1] != 1 {
+		t.Errorf("%d rows, missing %+v", d.Length(), mr)
+	}
+}
+
+func TestPredictSystem(t *testing.T) {
+	add := NewAdd()
+	add.Insert(NewVar(0))
+	add.Insert(NewSystem(0))
+	m := &Model{Eqn: NewEqn(add, 0)}
+	if y := m.PredictOne([]float64{1}, []float64{2}); y != 3 {
+		t.Errorf("x + S_0 at 1, 2 is %g", y)
+	}
+	if ys := m.Predict([][]float64{{1}, {5}}, []float64{-1}); ys[0] != 0 || ys[1] != 4 {
+		t.Errorf("x + S_0 at 1 and 5 with -1 is %v", ys)
+	}
+}
+
+func TestFitExperiments(t *testing.T) {
+	// y = 2x + 1 in one experiment and y = 4 - 3x in the other
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.data"), filepath.Join(dir, "b.data")}
+	texts := []string{
+		"x  y\n0 1\n1 3\n2 5\n3 7\n",
+		"x  y\n0 4\n1 1\n2 -2\n4 -8\n",
+	}
+	for i, text := range texts {
+		if err := ioutil.WriteFile(files[i], []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := ReadExperiments(files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1.5x + 0.5, the constants found by a search
+	mul := NewMul()
+	mul.Insert(NewConstantF(1.5))
+	mul.Insert(NewVar(0))
+	add := NewAdd()
+	add.Insert(mul)
+	add.Insert(NewConstantF(0.5))
+	r := &Result{Front: []*Model{newModel(NewEqn(add, 4), d)}}
+	r.FitExperiments(d, nil)
+
+	m := r.Front[0]
+	if len(m.Shared.consts) != 2 || len(m.Experiments) != 2 {
+		t.Fatalf("shared %v, %d experiments", m.Shared, len(m.Experiments))
+	}
+	want := [][]float64{{2, 1}, {-3, 4}}
+	for i, x := range m.Experiments {
+		if !x.Converged || x.Err > 1e-6 ||
+			math.Abs(x.Consts[0]-want[i][0]) > 1e-4 || math.Abs(x.Consts[1]-want[i][1]) > 1e-4 {
+			t.Errorf("experiment %s fit %v, error %g, converged %v, want %v", x.Name, x.Consts, x.Err, x.Converged, want[i])
+		}
+	}
+	// the model keeps the constants of the search
+	if y := m.PredictOne([]float64{2}, nil); y != 3.5 {
+		t.Errorf("the model changed, 3.5 at 2, not %g", y)
+	}
+}
+
+func TestMinimizeConverged(t *testing.T) {
+	bowl := func(x []float64) float64 { return (x[0]-3)*(x[0]-3) + (x[1]+1)*(x[1]+1) + 2 }
+	x, f, conv := minimize(bowl, []float64{0, 0}, 1000, FitTolerance)
+	if !conv || math.Abs(x[0]-3) > 1e-4 || math.Abs(x[1]+1) > 1e-4 || math.Abs(f-2) > 1e-9 {
+		t.Errorf("least at %v, %g, converged %v", x, f, conv)
+	}
+	if _, _, conv := minimize(bowl, []float64{0, 0}, 3, FitTolerance); conv {
+		t.Error("converged in 3 steps")
+	}
+}
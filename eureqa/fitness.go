@@ -0,0 +1,77 @@
+package eureqa
+
+import (
+	"math"
+)
+
+// Fitness scores equations on a DataSet. Islands evaluate offspring on
+// several goroutines, so Objectives must be safe for concurrent use.
+type Fitness interface {
+	// Objectives returns the values to minimize alongside the size, the
+	// first is the error shown in reports. ok false rejects the equation.
+	Objectives(e *Eqn, data *DataSet) (objs []float64, ok bool)
+}
+
+// FitnessFunc lets an ordinary function be a Fitness
+type FitnessFunc func(e *Eqn, data *DataSet) ([]float64, bool)
+
+func (f FitnessFunc) Objectives(e *Eqn, data *DataSet) ([]float64, bool) {
+	return f(e, data)
+}
+
+// EvalEach evaluates the equation at each row of data which isn't
+// Skipped, with the system values of its experiment, passing fn the row
+// and the value. It evaluates a node at a time down the columns.
+func (e *Eqn) EvalEach(data *DataSet, fn func(p int, y float64)) {
+	ce := getColEval(data)
+	defer ce.release()
+	ys := ce.eval(e.eqn)
+	for p, y := range ys {
+		if !data.Skipped(p) {
+			fn(p, y)
+		}
+	}
+	ce.put(ys)
+}
+
+// MAE is the default Fitness, the mean absolute error weighted by
+// the rows' Weight, over the rows which aren't Skipped for missing values
+type MAE struct{}
+
+func (MAE) Objectives(e *Eqn, data *DataSet) ([]float64, bool) {
+	err_sum, w_sum := 0.0, 0.0
+	e.EvalEach(data, func(p int, y float64) {
+		w := data.Weight(p)
+		err_sum += w * math.Abs(data.Output(p)-y)
+		w_sum += w
+	})
+
+	err := err_sum / w_sum
+	return []float64{err}, validErr(err)
+}
+
+// validErr rejects errors which are infinite, NaN or too large to matter
+func validErr(err float64) bool {
+	return !(err > 1e9 || math.IsInf(err, 0) || math.IsNaN(err))
+}
+
+// covers is true if a is at least as small and as good in every
+// objective as b. Equations with differing numbers of objectives,
+// such as those made by NewEqn, compare on those they share.
+func covers(a, b *Eqn) bool {
+	if a.size > b.size {
+		return false
+	}
+	ao, bo := a.objectives(), b.objectives()
+	for i := 0; i < len(ao) && i < len(bo); i++ {
+		if ao[i] > bo[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dominates is true if a covers b and is better in one respect
+func dominates(a, b *Eqn) bool {
+	return covers(a, b) && !covers(b, a)
+}
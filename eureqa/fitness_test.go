@@ -0,0 +1,117 @@
+package eureqa
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// objEqn is an equation with the given size and objectives
+func objEqn(size int, objs ...float64) *Eqn {
+	e := testEqn(size, objs[0])
+	e.objs = objs
+	return e
+}
+
+func TestParetoSortObjs(t *testing.T) {
+	eqns := []*Eqn{
+		objEqn(5, 0.5, 0.5, 0.5),
+		nil,
+		objEqn(3, 0.2, 0.9, 0.1), // front
+		objEqn(6, 0.5, 0.6, 0.5), // dominated by the first
+		objEqn(1, 1.0, 1.0, 1.0), // front, the smallest
+		nil,
+		objEqn(5, 0.4, 0.5, 0.5), // front, dominates the first
+		objEqn(3, 0.2, 0.9, 0.1), // a tie with the other size 3
+		objEqn(9, 0.1, 0.1, 0.9), // front
+	}
+	q := NewQueueFromArray(eqns)
+	q.ParetoSort()
+
+	// the first front, in order of size, then those it dominates
+	wantSizes := []int{1, 3, 3, 5, 9, 5, 6}
+	for i, s := range wantSizes {
+		if eqns[i] == nil || eqns[i].size != s {
+			t.Fatalf("position %d is %v, want size %d", i, eqns[i], s)
+		}
+	}
+	for i := len(wantSizes); i < len(eqns); i++ {
+		if eqns[i] != nil {
+			t.Errorf("position %d is %v, want the nils last", i, eqns[i])
+		}
+	}
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			if dominates(eqns[j], eqns[i]) {
+				t.Errorf("front member %d is dominated by %d", i, j)
+			}
+		}
+	}
+}
+
+// threeObjs scores the error, the largest error and the depth
+var threeObjs = FitnessFunc(func(e *Eqn, data *DataSet) ([]float64, bool) {
+	objs, ok := MAE{}.Objectives(e, data)
+	if !ok {
+		return nil, false
+	}
+	maxErr := 0.0
+	e.EvalEach(data, func(p int, y float64) {
+		maxErr = math.Max(maxErr, math.Abs(data.Output(p)-y))
+	})
+	return append(objs, maxErr, float64(e.eqn.Height())), validErr(maxErr)
+})
+
+func TestThreeObjectiveFront(t *testing.T) {
+	res, err := NewSearch(quadData(), WithSeed(2), WithGens(10), WithIslands(2, 30),
+		WithLockstep(), WithFitness(threeObjs)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Front) == 0 {
+		t.Fatal("empty front")
+	}
+	for i, a := range res.Front {
+		if len(a.Objectives()) != 3 {
+			t.Errorf("model %d has objectives %v", i, a.Objectives())
+		}
+		for j, b := range res.Front {
+			if i != j && covers(b.Eqn, a.Eqn) {
+				t.Errorf("model %d %v is covered by model %d %v", i, a.Eqn, j, b.Eqn)
+			}
+		}
+	}
+}
+
+func TestFitnessRejects(t *testing.T) {
+	// only equations of size 5 or less are acceptable
+	small := FitnessFunc(func(e *Eqn, data *DataSet) ([]float64, bool) {
+		objs, ok := MAE{}.Objectives(e, data)
+		return objs, ok && e.size <= 5
+	})
+
+	p := DefaultParams()
+	p.Fitness = small
+	I := &Island{params: p, data: quadData(), offs: []*Eqn{testEqn(3, -1), testEqn(8, -1), nil, testEqn(5, -1)}}
+	I.evalEqns()
+	if I.offs[0] == nil || I.offs[1] != nil || I.offs[2] != nil || I.offs[3] == nil {
+		t.Errorf("offspring after evaluation %v", I.offs)
+	}
+	if I.offs[0] != nil && I.offs[0].err < 0 {
+		t.Errorf("kept offspring has error %g", I.offs[0].err)
+	}
+
+	res, err := NewSearch(quadData(), WithSeed(4), WithGens(8), WithIslands(2, 30),
+		WithLockstep(), WithFitness(small)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Front) == 0 {
+		t.Fatal("empty front")
+	}
+	for _, m := range res.Front {
+		if m.Size() > 5 {
+			t.Errorf("rejected model %v in the front", m.Eqn)
+		}
+	}
+}
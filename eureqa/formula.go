@@ -0,0 +1,291 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// powers up to this are written out as multiplications
+const maxIntPower = 8
+
+// ParseFormula parses an equation over the named variables, such as
+// "sqrt(x^2 + y^2)" with vars x and y, whose Var nodes are the positions
+// of the names in vars. Formulas have numbers, pi, + - * / ^, parentheses,
+// and the functions abs, sqrt, sin, cos, tan, exp, log and the registered
+// primitives.
+func ParseFormula(src string, vars []string) (Expr, error) {
+	fp := &formulaParser{src: src, vars: vars}
+	fp.next()
+	e := fp.sum()
+	if fp.err == nil && fp.tok != "" {
+		fp.fail("unexpected %q", fp.tok)
+	}
+	if fp.err != nil {
+		return nil, fp.err
+	}
+	e.CalcExprStats()
+	return e, nil
+}
+
+// ParseDerived parses a derived column definition, "name = formula"
+func ParseDerived(def string, vars []string) (name string, e Expr, err error) {
+	eq := strings.Index(def, "=")
+	if eq < 0 {
+		return "", nil, fmt.Errorf("derived column %q: want name = formula", def)
+	}
+	name = strings.TrimSpace(def[:eq])
+	if name == "" {
+		return "", nil, fmt.Errorf("derived column %q: no name", def)
+	}
+	if e, err = ParseFormula(def[eq+1:], vars); err != nil {
+		return "", nil, fmt.Errorf("derived column %s: %v", name, err)
+	}
+	return name, e, nil
+}
+
+// formulaParser is a recursive descent parser,
+// one method per level of precedence
+type formulaParser struct {
+	src  string
+	vars []string
+
+	pos, start int    // after the current token, and where it began
+	tok        string // the current token, "" at the end
+	err        error  // the first error, parsing stops with it
+}
+
+func (fp *formulaParser) fail(format string, args ...interface{}) {
+	if fp.err == nil {
+		fp.err = fmt.Errorf("formula %q col %d: %s", fp.src, fp.start+1, fmt.Sprintf(format, args...))
+	}
+}
+
+// next moves to the next token, a number, a name or a symbol
+func (fp *formulaParser) next() {
+	for fp.pos < len(fp.src) && unicode.IsSpace(rune(fp.src[fp.pos])) {
+		fp.pos++
+	}
+	fp.start = fp.pos
+	if fp.pos == len(fp.src) || fp.err != nil {
+		fp.tok = ""
+		return
+	}
+
+	c := fp.src[fp.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for fp.pos < len(fp.src) && strings.IndexByte("0123456789.eE", fp.src[fp.pos]) >= 0 {
+			// an exponent's sign belongs to the number
+			if e := fp.src[fp.pos]; (e == 'e' || e == 'E') && fp.pos+1 < len(fp.src) && strings.IndexByte("+-", fp.src[fp.pos+1]) >= 0 {
+				fp.pos++
+			}
+			fp.pos++
+		}
+	case isNameByte(c):
+		for fp.pos < len(fp.src) && (isNameByte(fp.src[fp.pos]) || fp.src[fp.pos] >= '0' && fp.src[fp.pos] <= '9') {
+			fp.pos++
+		}
+	default:
+		fp.pos++
+	}
+	fp.tok = fp.src[fp.start:fp.pos]
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// sum: product {(+|-) product}
+func (fp *formulaParser) sum() Expr {
+	terms := []Expr{fp.product()}
+	for fp.tok == "+" || fp.tok == "-" {
+		neg := fp.tok == "-"
+		fp.next()
+		t := fp.product()
+		if neg {
+			t = NewNeg(t)
+		}
+		terms = append(terms, t)
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	add := NewAdd()
+	for _, t := range terms {
+		add.Insert(t)
+	}
+	return add
+}
+
+// product: unary {(*|/) unary}
+func (fp *formulaParser) product() Expr {
+	e := fp.unary()
+	var mul *Mul
+	for fp.tok == "*" || fp.tok == "/" {
+		div := fp.tok == "/"
+		fp.next()
+		f := fp.unary()
+		switch {
+		case div:
+			if mul != nil {
+				e, mul = mul, nil
+			}
+			e = NewDiv(e, f)
+		case mul == nil:
+			mul = NewMul()
+			mul.Insert(e)
+			mul.Insert(f)
+		default:
+			mul.Insert(f)
+		}
+	}
+	if mul != nil {
+		return mul
+	}
+	return e
+}
+
+// unary: -unary | power
+func (fp *formulaParser) unary() Expr {
+	if fp.tok == "-" {
+		fp.next()
+		e := fp.unary()
+		if c, ok := e.(*ConstantF); ok {
+			return NewConstantF(-c.F)
+		}
+		return NewNeg(e)
+	}
+	if fp.tok == "+" {
+		fp.next()
+		return fp.unary()
+	}
+	return fp.power()
+}
+
+// power: atom [^ unary], right associative through unary
+func (fp *formulaParser) power() Expr {
+	base := fp.atom()
+	if fp.tok != "^" {
+		return base
+	}
+	fp.next()
+	exp := fp.unary()
+
+	c, isConst := exp.(*ConstantF)
+	if !isConst || c.F != math.Trunc(c.F) || c.F == 0 || math.Abs(c.F) > maxIntPower {
+		return NewUserFunc(POW, base, exp)
+	}
+	e := base
+	if n := int(math.Abs(c.F)); n > 1 {
+		mul := NewMul()
+		for i := 0; i < n; i++ {
+			if i == 0 {
+				mul.Insert(base)
+			} else {
+				mul.Insert(base.Clone())
+			}
+		}
+		e = mul
+	}
+	if c.F < 0 {
+		e = NewDiv(NewConstantF(1), e)
+	}
+	return e
+}
+
+// atom: number | name | name(args) | (sum)
+func (fp *formulaParser) atom() Expr {
+	tok := fp.tok
+	switch {
+	case tok == "":
+		fp.fail("unexpected end")
+		return NewNull()
+
+	case tok == "(":
+		fp.next()
+		e := fp.sum()
+		fp.expect(")")
+		return e
+
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '.':
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			fp.fail("bad number %q", tok)
+		}
+		fp.next()
+		return NewConstantF(f)
+
+	case isNameByte(tok[0]):
+		start := fp.start
+		fp.next()
+		if fp.tok == "(" {
+			return fp.call(tok, start)
+		}
+		for p, v := range fp.vars {
+			if v == tok {
+				return NewVar(p)
+			}
+		}
+		if tok == "pi" {
+			return NewConstantF(math.Pi)
+		}
+		fp.start = start
+		fp.fail("unknown variable %q", tok)
+		return NewNull()
+	}
+	fp.fail("unexpected %q", tok)
+	return NewNull()
+}
+
+// call parses the arguments of the function name, which began at start
+func (fp *formulaParser) call(name string, start int) Expr {
+	fp.next()
+	var args []Expr
+	for fp.tok != ")" && fp.err == nil {
+		args = append(args, fp.sum())
+		if fp.tok != "," {
+			break
+		}
+		fp.next()
+	}
+	fp.expect(")")
+
+	unary := map[string]func(Expr) Expr{
+		"abs":  func(e Expr) Expr { return NewAbs(e) },
+		"sqrt": func(e Expr) Expr { return NewSqrt(e) },
+		"sin":  func(e Expr) Expr { return NewSin(e) },
+		"cos":  func(e Expr) Expr { return NewCos(e) },
+		"tan":  func(e Expr) Expr { return NewTan(e) },
+		"exp":  func(e Expr) Expr { return NewExp(e) },
+		"log":  func(e Expr) Expr { return NewLog(e) },
+	}
+	fp.start = start
+	if f, ok := unary[name]; ok {
+		if len(args) != 1 {
+			fp.fail("%s takes 1 argument, not %d", name, len(args))
+			return NewNull()
+		}
+		return f(args[0])
+	}
+	if t, ok := PrimitiveByName(name); ok {
+		if len(args) != primitive(t).Arity {
+			fp.fail("%s takes %d arguments, not %d", name, primitive(t).Arity, len(args))
+			return NewNull()
+		}
+		return NewUserFunc(t, args...)
+	}
+	fp.fail("unknown function %q", name)
+	return NewNull()
+}
+
+func (fp *formulaParser) expect(tok string) {
+	if fp.tok != tok {
+		fp.fail("expected %q", tok)
+	}
+	fp.next()
+}
@@ -0,0 +1,143 @@
+package eureqa
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+func TestParseFormula(t *testing.T) {
+	vars := []string{"x", "y"}
+	x := []float64{3, 2}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"x - y - 1", 0},
+		{"x / y / 2", 0.75},
+		{"2^3^2", 512},
+		{"-x^2", -9},
+		{"-2^2", -4},
+		{"2 * -x", -6},
+		{"+x", 3},
+		{"x^-2", 1.0 / 9},
+		{"y^0.5", math.Sqrt2},
+		{"x^9", 19683},
+		{"2 * pi", 2 * math.Pi},
+		{"sqrt(x^2 + 16)", 5},
+		{"abs(y - x) + exp(0) + log(1)", 2},
+		{"pow(y, 3)", 8},
+		{"tanh(0) + sin(0) + cos(0)", 1},
+		{"1.5e1", 15},
+	}
+	for _, tt := range tests {
+		e, err := ParseFormula(tt.src, vars)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if got := e.Eval(0, x, nil, nil); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%q is %g at x=3 y=2, want %g", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseFormulaPowers(t *testing.T) {
+	isPow := func(e Expr) bool {
+		u, ok := e.(*UserFunc)
+		return ok && u.T == POW
+	}
+	tests := []struct {
+		src string
+		pow bool // left as a pow primitive
+	}{
+		{"x^2", false},
+		{"x^8", false},
+		{"x^-8", false},
+		{"x^9", true},
+		{"x^-9", true},
+		{"x^0", true},
+		{"x^1.5", true},
+		{"x^y", true},
+	}
+	for _, tt := range tests {
+		e, err := ParseFormula(tt.src, []string{"x", "y"})
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if isPow(e) != tt.pow {
+			t.Errorf("%q parsed to %v, pow %v", tt.src, e, isPow(e))
+		}
+	}
+	if e, _ := ParseFormula("x^3", []string{"x"}); e.ExprType() != MUL || e.NumChildren() != 3 {
+		t.Errorf("x^3 parsed to %v", e)
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	for _, src := range []string{
+		"q + 1",     // unknown variable
+		"foo(x)",    // unknown function
+		"sin(x, x)", // too many arguments
+		"pow(x)",    // too few
+		"1 +",
+		"(x",
+		"x)",
+		"x $ 2",
+		"",
+	} {
+		if _, err := ParseFormula(src, []string{"x"}); err == nil {
+			t.Errorf("no error parsing %q", src)
+		}
+	}
+}
+
+func TestParseDerived(t *testing.T) {
+	name, e, err := ParseDerived(" r = sqrt(x^2 + y^2)", []string{"x", "y"})
+	if err != nil || name != "r" || e.Eval(0, []float64{3, 4}, nil, nil) != 5 {
+		t.Errorf("name %q, formula %v, error %v", name, e, err)
+	}
+	for _, def := range []string{"r sqrt(x)", "= x", "r = z"} {
+		if _, _, err := ParseDerived(def, []string{"x"}); err == nil {
+			t.Errorf("no error parsing %q", def)
+		}
+	}
+}
+
+func TestReadDerived(t *testing.T) {
+	const text = "x y w out\n1 2 1 3\n2 3 2 5\n4 5 1 9\n"
+	d, err := readString(t, text, &ReadOptions{
+		Derived: []string{"r = x * y", "s = r + y"},
+		Exclude: []string{"y"},
+		Weight:  "w",
+		Target:  "out",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"x", "r", "s"}; !reflect.DeepEqual(d.VarNames(), want) || d.OutName() != "out" {
+		t.Fatalf("columns %v %s, want %v out", d.VarNames(), d.OutName(), want)
+	}
+	if r, s := d.Column(1), d.Column(2); !reflect.DeepEqual(r, []float64{2, 6, 20}) || !reflect.DeepEqual(s, []float64{4, 9, 25}) {
+		t.Errorf("r %v, s %v", r, s)
+	}
+	if d.Weight(1) != 2 {
+		t.Errorf("weight %g", d.Weight(1))
+	}
+
+	for _, opts := range []*ReadOptions{
+		{Derived: []string{"x = y + 1"}},                     // already a column
+		{Derived: []string{"r = s", "s = x"}},                // s comes later
+		{Derived: []string{"r = x"}, Weight: "r", Time: "r"}, // two roles
+		{Derived: []string{"r = x"}, Weight: "nope"},         // unknown role column
+	} {
+		if _, err := readString(t, text, opts); err == nil {
+			t.Errorf("no error reading with %+v", opts)
+		}
+	}
+}
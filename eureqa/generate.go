@@ -0,0 +1,292 @@
+package eureqa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// how GenerateData picks the input points
+type Sampling int
+
+const (
+	SAMPLE_GRID    Sampling = iota // evenly spaced, every combination
+	SAMPLE_UNIFORM                 // uniformly at random
+	SAMPLE_LHS                     // a Latin hypercube, one point in each nth of every range
+)
+
+func ParseSampling(name string) (Sampling, error) {
+	switch name {
+	case "grid":
+		return SAMPLE_GRID, nil
+	case "uniform":
+		return SAMPLE_UNIFORM, nil
+	case "lhs":
+		return SAMPLE_LHS, nil
+	default:
+		return 0, fmt.Errorf("unknown sampling %q", name)
+	}
+}
+
+// how GenerateData adds noise to the output
+type Noise int
+
+const (
+	NOISE_GAUSSIAN Noise = iota // y + N(0, level)
+	NOISE_RELATIVE              // y * (1 + N(0, level))
+)
+
+func ParseNoise(name string) (Noise, error) {
+	switch name {
+	case "gaussian":
+		return NOISE_GAUSSIAN, nil
+	case "relative":
+		return NOISE_RELATIVE, nil
+	default:
+		return 0, fmt.Errorf("unknown noise %q", name)
+	}
+}
+
+// VarRange is the range an input is sampled from
+type VarRange struct {
+	Name     string
+	Min, Max float64
+}
+
+// ParseVarRange reads a range such as "x=-3:3"
+func ParseVarRange(spec string) (VarRange, error) {
+	var vr VarRange
+	eq := strings.Index(spec, "=")
+	colon := strings.LastIndex(spec, ":")
+	if eq <= 0 || colon < eq {
+		return vr, fmt.Errorf("variable range %q: want name=min:max", spec)
+	}
+	vr.Name = strings.TrimSpace(spec[:eq])
+	var err error
+	if vr.Min, err = strconv.ParseFloat(strings.TrimSpace(spec[eq+1:colon]), 64); err != nil {
+		return vr, fmt.Errorf("variable range %q: can't parse the minimum", spec)
+	}
+	if vr.Max, err = strconv.ParseFloat(strings.TrimSpace(spec[colon+1:]), 64); err != nil {
+		return vr, fmt.Errorf("variable range %q: can't parse the maximum", spec)
+	}
+	return vr, nil
+}
+
+// GenOptions say what data GenerateData makes
+type GenOptions struct {
+	// "name = formula", or a formula for ParseFormula
+	// whose output is named like f(x,y)
+	Formula string
+	// the inputs of the formula and their ranges
+	Vars []VarRange
+
+	Sampling Sampling
+	// the number of points, per variable for SAMPLE_GRID
+	N int
+
+	// the noise added to the output, with
+	// standard deviation Level, none when 0
+	Noise Noise
+	Level float64
+
+	// the sampling and noise come from Rng, which
+	// only SAMPLE_GRID without noise can do without
+	Rng *rand.Rand
+}
+
+// GenerateData samples the inputs in their ranges and evaluates
+// the formula at each point, adding noise, to make test data
+// whose equation is known. The formula must be finite at every
+// point, the data would otherwise read back with missing values.
+func GenerateData(opts *GenOptions) (*DataSet, error) {
+	names := make([]string, len(opts.Vars))
+	for v, vr := range opts.Vars {
+		names[v] = vr.Name
+		if err := checkName(vr.Name, names[:v]); err != nil {
+			return nil, err
+		}
+		if !(vr.Min <= vr.Max) || math.IsInf(vr.Min, 0) || math.IsInf(vr.Max, 0) {
+			return nil, fmt.Errorf("variable %s has range %g:%g", vr.Name, vr.Min, vr.Max)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no variables to sample")
+	}
+	if opts.N < 1 {
+		return nil, fmt.Errorf("%d points, there must be at least 1", opts.N)
+	}
+	if !(opts.Level >= 0) || math.IsInf(opts.Level, 0) {
+		return nil, fmt.Errorf("noise level %g", opts.Level)
+	}
+	if opts.Rng == nil && (opts.Sampling != SAMPLE_GRID || opts.Level > 0) {
+		return nil, fmt.Errorf("random sampling and noise need a random source, Rng")
+	}
+
+	outName, src := "f("+strings.Join(names, ",")+")", opts.Formula
+	if eq := strings.Index(src, "="); eq >= 0 {
+		outName, src = strings.TrimSpace(src[:eq]), src[eq+1:]
+	}
+	if err := checkName(outName, names); err != nil {
+		return nil, err
+	}
+	e, err := ParseFormula(src, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var points [][]float64
+	switch opts.Sampling {
+	case SAMPLE_GRID:
+		points, err = gridPoints(opts.Vars, opts.N)
+	case SAMPLE_UNIFORM:
+		points = uniformPoints(opts.Vars, opts.N, opts.Rng)
+	case SAMPLE_LHS:
+		points = lhsPoints(opts.Vars, opts.N, opts.Rng)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]float64, len(points))
+	bad, first := 0, -1 // points where the formula isn't finite
+	for p, x := range points {
+		y := e.Eval(0, x, nil, nil)
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			if bad++; first < 0 {
+				first = p
+			}
+			continue
+		}
+		if opts.Level > 0 {
+			switch opts.Noise {
+			case NOISE_GAUSSIAN:
+				y += opts.Level * opts.Rng.NormFloat64()
+			case NOISE_RELATIVE:
+				y *= 1 + opts.Level*opts.Rng.NormFloat64()
+			}
+		}
+		output[p] = y
+	}
+	if bad > 0 {
+		at := make([]string, len(names))
+		for v, name := range names {
+			at[v] = name + "=" + strconv.FormatFloat(points[first][v], 'g', -1, 64)
+		}
+		return nil, fmt.Errorf("the formula is NaN or infinite at %d of the %d points, such as %s",
+			bad, len(points), strings.Join(at, " "))
+	}
+	return NewDataSet(points, output, names, outName), nil
+}
+
+// checkName rejects names a data file can't hold, or already used
+func checkName(name string, used []string) error {
+	if name == "" || strings.IndexFunc(name, unicode.IsSpace) >= 0 || strings.ContainsRune(name, '"') || name[0] == '#' {
+		return fmt.Errorf("bad column name %q", name)
+	}
+	for _, u := range used {
+		if u == name {
+			return fmt.Errorf("duplicate column name %q", name)
+		}
+	}
+	return nil
+}
+
+// gridPoints is n evenly spaced values of each variable,
+// in every combination, the first variable changing fastest
+func gridPoints(vars []VarRange, n int) ([][]float64, error) {
+	total := 1
+	for range vars {
+		if total > math.MaxInt32/n {
+			return nil, fmt.Errorf("a grid of %d points on %d variables is too large", n, len(vars))
+		}
+		total *= n
+	}
+	points := make([][]float64, total)
+	for p := range points {
+		x, i := make([]float64, len(vars)), p
+		for v, vr := range vars {
+			x[v] = vr.Min
+			if n > 1 {
+				x[v] += float64(i%n) * (vr.Max - vr.Min) / float64(n-1)
+			}
+			i /= n
+		}
+		points[p] = x
+	}
+	return points, nil
+}
+
+func uniformPoints(vars []VarRange, n int, rng *rand.Rand) [][]float64 {
+	points := make([][]float64, n)
+	for p := range points {
+		points[p] = make([]float64, len(vars))
+		for v, vr := range vars {
+			points[p][v] = vr.Min + rng.Float64()*(vr.Max-vr.Min)
+		}
+	}
+	return points
+}
+
+// lhsPoints puts one point in each nth of the range of every variable,
+// pairing the nths of the variables at random
+func lhsPoints(vars []VarRange, n int, rng *rand.Rand) [][]float64 {
+	points := make([][]float64, n)
+	for p := range points {
+		points[p] = make([]float64, len(vars))
+	}
+	for v, vr := range vars {
+		for p, bin := range rng.Perm(n) {
+			points[p][v] = vr.Min + (float64(bin)+rng.Float64())*(vr.Max-vr.Min)/float64(n)
+		}
+	}
+	return points
+}
+
+// Write writes d in the format ReadDataSetFile reads, a header of the
+// column names and then the rows, space separated, with the values of the
+// system parameters when d is one experiment. The weight and time columns,
+// when d has them, come before the output, which stays the last column,
+// so ReadOptions naming them as Weight and Time read d back.
+func (d *DataSet) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if len(d.sys_names) > 0 && d.Experiments() == 1 {
+		fmt.Fprint(bw, "# system:")
+		for s, name := range d.sys_names {
+			fmt.Fprintf(bw, " %s=%s", name, strconv.FormatFloat(d.sys[0][s], 'g', -1, 64))
+		}
+		fmt.Fprintln(bw)
+	}
+
+	// the columns in the order of the file, the output last
+	nv, order := len(d.var_names), []int{}
+	for c := 0; c < d.columns(); c++ {
+		if c != nv {
+			order = append(order, c)
+		}
+	}
+	order = append(order, nv)
+	names := make([]string, len(order))
+	for i, c := range order {
+		names[i] = d.colName(c)
+	}
+	fmt.Fprintln(bw, strings.Join(names, "  "))
+
+	var line []byte
+	for p := range d.output {
+		line = line[:0]
+		for i, c := range order {
+			if i > 0 {
+				line = append(line, ' ', ' ')
+			}
+			line = strconv.AppendFloat(line, d.cell(p, c), 'g', -1, 64)
+		}
+		line = append(line, '\n')
+		bw.Write(line)
+	}
+	return bw.Flush()
+}
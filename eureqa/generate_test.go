@@ -0,0 +1,181 @@
+package eureqa
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func genOpts(sampling Sampling, n int) *GenOptions {
+	return &GenOptions{
+		Formula:  "y = x*x + z",
+		Vars:     []VarRange{{"x", -1, 1}, {"z", 0, 2}},
+		Sampling: sampling,
+		N:        n,
+		Rng:      rand.New(rand.NewSource(3)),
+	}
+}
+
+func TestGenerateGrid(t *testing.T) {
+	d, err := GenerateData(genOpts(SAMPLE_GRID, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Length() != 9 || d.OutName() != "y" {
+		t.Fatalf("%d rows of %s", d.Length(), d.OutName())
+	}
+	// x changes fastest
+	xs, zs := []float64{-1, 0, 1}, []float64{0, 1, 2}
+	for p := 0; p < 9; p++ {
+		x, z := d.Column(0)[p], d.Column(1)[p]
+		if x != xs[p%3] || z != zs[p/3] || d.Output(p) != x*x+z {
+			t.Errorf("row %d is %g %g %g", p, x, z, d.Output(p))
+		}
+	}
+}
+
+func TestGenerateRandom(t *testing.T) {
+	const n = 50
+	for _, s := range []Sampling{SAMPLE_UNIFORM, SAMPLE_LHS} {
+		d, err := GenerateData(genOpts(s, n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Length() != n {
+			t.Fatalf("sampling %d: %d rows", s, d.Length())
+		}
+		for v, vr := range []VarRange{{"x", -1, 1}, {"z", 0, 2}} {
+			bins := make([]int, n)
+			for _, x := range d.Column(v) {
+				if x < vr.Min || x > vr.Max {
+					t.Errorf("sampling %d: %s = %g out of range", s, vr.Name, x)
+					continue
+				}
+				bins[int(math.Min(float64(n-1), (x-vr.Min)/(vr.Max-vr.Min)*n))]++
+			}
+			if s != SAMPLE_LHS {
+				continue
+			}
+			// one point in each nth of the range
+			for b, cnt := range bins {
+				if cnt != 1 {
+					t.Errorf("lhs: %d points of %s in bin %d", cnt, vr.Name, b)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateNoise(t *testing.T) {
+	const level = 0.1
+	for _, noise := range []Noise{NOISE_GAUSSIAN, NOISE_RELATIVE} {
+		opts := genOpts(SAMPLE_UNIFORM, 4000)
+		opts.Noise, opts.Level = noise, level
+		d, err := GenerateData(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the noise, relative to the output for NOISE_RELATIVE
+		sum, sq := 0.0, 0.0
+		for p := 0; p < d.Length(); p++ {
+			x, z := d.Column(0)[p], d.Column(1)[p]
+			e := d.Output(p) - (x*x + z)
+			if noise == NOISE_RELATIVE {
+				e /= x*x + z
+			}
+			sum += e
+			sq += e * e
+		}
+		mean := sum / float64(d.Length())
+		sd := math.Sqrt(sq/float64(d.Length()) - mean*mean)
+		if math.Abs(mean) > 0.01 || math.Abs(sd-level) > 0.01 {
+			t.Errorf("noise %d: mean %g and sd %g, want 0 and %g", noise, mean, sd, level)
+		}
+	}
+
+	// seeded data is the same
+	a, _ := GenerateData(genOpts(SAMPLE_LHS, 20))
+	b, _ := GenerateData(genOpts(SAMPLE_LHS, 20))
+	for p := 0; p < a.Length(); p++ {
+		if a.Column(0)[p] != b.Column(0)[p] || a.Output(p) != b.Output(p) {
+			t.Fatalf("row %d differs between runs with one seed", p)
+		}
+	}
+}
+
+func TestParseGenNames(t *testing.T) {
+	if s, err := ParseSampling("lhs"); err != nil || s != SAMPLE_LHS {
+		t.Errorf("lhs is %d, %v", s, err)
+	}
+	if n, err := ParseNoise("relative"); err != nil || n != NOISE_RELATIVE {
+		t.Errorf("relative is %d, %v", n, err)
+	}
+	if _, err := ParseSampling("sobol"); err == nil {
+		t.Error("no error for an unknown sampling")
+	}
+	if _, err := ParseNoise("pink"); err == nil {
+		t.Error("no error for an unknown noise")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	opts := genOpts(SAMPLE_GRID, 3)
+	opts.Rng = nil
+	if _, err := GenerateData(opts); err != nil {
+		t.Errorf("grid without a random source: %v", err)
+	}
+	opts.Level = 0.1
+	if _, err := GenerateData(opts); err == nil {
+		t.Error("no error for noise without a random source")
+	}
+	opts.Sampling, opts.Level = SAMPLE_LHS, 0
+	if _, err := GenerateData(opts); err == nil {
+		t.Error("no error for lhs without a random source")
+	}
+	opts = genOpts(SAMPLE_GRID, 3)
+	opts.Level = math.NaN()
+	if _, err := GenerateData(opts); err == nil {
+		t.Error("no error for a NaN noise level")
+	}
+
+	// log of 0 and sqrt of -1
+	for _, f := range []string{"y = log(x + 1)", "y = sqrt(x)"} {
+		opts = genOpts(SAMPLE_GRID, 3)
+		opts.Formula = f
+		if _, err := GenerateData(opts); err == nil || !strings.Contains(err.Error(), "3 of the 9 points") {
+			t.Errorf("%s: error %v", f, err)
+		}
+	}
+}
+
+func TestWriteReadBack(t *testing.T) {
+	const text = "# system: k=2\nx  w  t  z  y\n1 0.5 0 2 3\n-1.25 1 1 NaN 4e-3\n7 2 2 1 -8\n"
+	opts := &ReadOptions{Weight: "w", Time: "t", Missing: &MissingPolicy{Default: MISS_SKIP}}
+	d, err := readString(t, text, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "# system: k=2\nx  z  w  t  y\n") {
+		t.Errorf("wrote %q", buf.String())
+	}
+	r, err := readString(t, buf.String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c := 0; c < d.columns(); c++ {
+		for p := 0; p < d.Length(); p++ {
+			if a, b := d.cell(p, c), r.cell(p, c); a != b && !(math.IsNaN(a) && math.IsNaN(b)) {
+				t.Errorf("%s at row %d read back as %g, want %g", d.colName(c), p, b, a)
+			}
+		}
+	}
+	if r.SysVals(0)[0] != 2 || !r.Skipped(1) {
+		t.Errorf("system values %v, row 1 skipped %v", r.SysVals(0), r.Skipped(1))
+	}
+}
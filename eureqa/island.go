@@ -0,0 +1,364 @@
+package eureqa
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	. "github.com/verdverm/go-symexpr"
+
+	"github.com/verdverm/go-eureqa/peval"
+)
+
+// commands sent from the Search goroutine to an Island
+type IslandCmd int
+
+const (
+	NULL_CMD IslandCmd = iota
+	ISLE_RUN
+	ISLE_STEP
+	ISLE_REPORT
+	ISLE_PAUSE
+	ISLE_QUIT
+)
+
+type Island struct {
+	// global info
+	Id int
+
+	// parameters
+	params *Params
+	prof   *IslandProfile
+	treep  *TreeParams // private copy, breeding modifies the Curr/Tmp fields
+
+	// communication
+	cmds   chan IslandCmd
+	report ReportChan
+	migIn  MigrantChan
+	migOut []MigrantChan // indexed by island id
+	topo   Topology
+
+	// internal data
+	iters  int
+	epoch  int          // migrations so far
+	lastMg time.Time    // time of the last migration
+	sent   []*Migration // this generation's, for the report
+	recvd  int          // immigrant equations merged this generation
+	paused bool
+	stop   bool
+	data   *DataSet
+
+	rng *rand.Rand
+
+	eqns []*Eqn      // best equations
+	offs []*Eqn      // offspring equations
+	migs []*Eqn      // immigrant equations
+	held []*Migrants // migrants from the future, in lockstep mode
+}
+
+func newIsland(id int, srp *Params, prof *IslandProfile, data *DataSet, rpt ReportChan, migs []MigrantChan, topo Topology) *Island {
+	isle := new(Island)
+	isle.Id = id
+	isle.params = srp
+	isle.prof = prof
+	isle.treep = prof.Tree.Clone()
+	isle.data = data
+	isle.cmds = make(chan IslandCmd)
+	isle.report = rpt
+	isle.migIn = migs[id]
+	isle.migOut = migs
+	isle.topo = topo
+	isle.lastMg = time.Now()
+	isle.paused = true // until the Search says how to run
+	return isle
+}
+
+func (I *Island) initIsland() {
+	// initialize internal structs
+	I.rng = rand.New(rand.NewSource(islandSeed(I.params.Seed, I.Id)))
+
+	// create initial eqns
+	I.initEqns()
+
+}
+
+// run is the Island's goroutine. Islands start paused, stepping only
+// on ISLE_STEP, until ISLE_RUN lets them step freely up to params.Gens
+// generations, checking for commands between generations.
+// Quit is acknowledged by echoing ISLE_QUIT back.
+func (I *Island) run() {
+	for !I.stop {
+		if I.paused || I.iters >= I.params.Gens {
+			// nothing to do, so wait for a command
+			I.command(<-I.cmds)
+			continue
+		}
+		I.messages()
+		if !I.paused && !I.stop {
+			I.step()
+		}
+	}
+	I.cmds <- ISLE_QUIT
+}
+
+func (I *Island) messages() {
+	for !I.stop {
+		select {
+		case cmd := <-I.cmds:
+			I.command(cmd)
+		default:
+			return // so we don't wait indefinitely for a command
+		}
+	}
+}
+
+func (I *Island) command(cmd IslandCmd) {
+	switch cmd {
+	case ISLE_RUN:
+		I.paused = false
+	case ISLE_STEP:
+		I.step()
+	case ISLE_REPORT:
+		I.reportEqns()
+	case ISLE_PAUSE:
+		I.paused = true
+	case ISLE_QUIT:
+		I.stop = true
+	}
+}
+
+// islandSeed derives an island's seed from the master seed,
+// so it doesn't depend on the order the islands are started in
+func islandSeed(master int64, id int) int64 {
+	// splitmix64 finalizer
+	z := uint64(master) + uint64(id+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+func (I *Island) step() {
+	I.recvMigrants() // first, as a restart replaces the offspring
+	I.evalEqns()
+	I.selectEqns()
+	I.sendMigrants() // before reporting, so lockstep runs see them next gen
+	I.reportEqns()
+	I.breedEqns()
+	I.iters++
+}
+
+func (I *Island) reportEqns() {
+
+	eqns := make([]*Eqn, I.params.RptSize)
+	copy(eqns, I.eqns[:I.params.RptSize])
+	I.report <- &IslandReport{I.Id, I.iters, eqns, I.sent, I.recvd}
+
+}
+
+// sendMigrants sends clones of equations chosen by the selection
+// policy to this epoch's neighbors, dropping them for any neighbor
+// whose inbox is full. Migration happens every MigInterval of wall
+// clock time when that is set, otherwise every MigEpoch generations.
+// Epochs count the island's own migrations, so with MigInterval the
+// islands reach an epoch at different times and generations, and a
+// topology which changes by epoch, as random does, is only a shared
+// graph per round of migration when migrating by MigEpoch.
+func (I *Island) sendMigrants() {
+	I.sent = nil
+	if I.params.MigInterval > 0 {
+		if time.Since(I.lastMg) < I.params.MigInterval {
+			return
+		}
+	} else if I.params.MigEpoch <= 0 || (I.iters+1)%I.params.MigEpoch != 0 {
+		return
+	}
+	I.lastMg = time.Now()
+
+	for _, n := range I.topo.Neighbors(I.Id, I.epoch) {
+		// each neighbor gets its own clones
+		mig := &Migrants{I.Id, I.iters, make([]*Eqn, 0, I.params.MigCount), false}
+		for _, e := range I.selectEmigrants(I.params.MigCount) {
+			mig.Eqns = append(mig.Eqns, I.eqns[e].Clone())
+		}
+
+		select {
+		case I.migOut[n] <- mig:
+			I.sent = append(I.sent, &Migration{I.Id, n, I.iters, len(mig.Eqns)})
+		default:
+			// don't block the step loop on a busy neighbor
+		}
+	}
+	I.epoch++
+}
+
+// recvMigrants collects whatever has arrived in the inbox. Migrants
+// are merged in order of generation and sender, and in lockstep mode
+// only those sent in earlier generations are taken, so that seeded
+// runs are reproducible.
+func (I *Island) recvMigrants() {
+	arrived := I.held
+	I.held = nil
+	for more := true; more; {
+		select {
+		case mig := <-I.migIn:
+			arrived = append(arrived, mig)
+		default:
+			more = false
+		}
+	}
+
+	I.recvd = 0
+	sort.Stable(MigrantsArray(arrived))
+	for _, mig := range arrived {
+		if I.params.SyncReports && mig.Gen >= I.iters {
+			I.held = append(I.held, mig)
+			continue
+		}
+		if mig.Restart {
+			I.restart()
+		}
+		I.migs = append(I.migs, mig.Eqns...)
+		I.recvd += len(mig.Eqns)
+	}
+}
+
+// restart reseeds the island with random equations,
+// keeping the best params.RestartElites of the current ones
+func (I *Island) restart() {
+	elites := make([]*Eqn, 0, I.params.RestartElites)
+	for _, e := range I.eqns {
+		if len(elites) == cap(elites) {
+			break
+		}
+		if e != nil {
+			elites = append(elites, e)
+		}
+	}
+
+	I.initEqns()
+	copy(I.eqns, elites)
+}
+
+// evalEqns spreads the offspring over params.EvalWorkers goroutines,
+// each equation is still summed serially so errors are deterministic
+func (I *Island) evalEqns() {
+	peval.For(len(I.offs), I.params.EvalWorkers, I.evalEqn)
+}
+
+func (I *Island) evalEqn(e int) {
+	if I.offs[e] == nil {
+		return
+	}
+	objs, ok := I.params.Fitness.Objectives(I.offs[e], I.data)
+	if !ok || len(objs) == 0 {
+		I.offs[e] = nil
+		return
+	}
+	I.offs[e].objs = objs
+	I.offs[e].err = objs[0]
+}
+
+func (I *Island) selectEqns() {
+
+	// immigrants either displace residents or join the pool
+	migs := I.displaceResidents(I.migs)
+	I.migs = I.migs[:0]
+
+	// collect all of the equations
+	temp := make([]*Eqn, len(I.eqns)+len(I.offs)+len(migs))
+	copy(temp, I.eqns)
+	copy(temp[len(I.eqns):], I.offs)
+	copy(temp[len(I.eqns)+len(I.offs):], migs)
+
+	// filter so we only have unique equations
+	sort.Sort(EqnArray(temp))
+	last := 0
+	for last < len(temp)-1 && temp[last] == nil {
+		last++
+	}
+	for i := last + 1; i < len(temp); i++ {
+		if temp[i] == nil {
+			continue
+		}
+		if temp[i].eqn.AmIAlmostSame(temp[last].eqn) {
+			temp[i] = nil
+		} else {
+			last = i
+		}
+	}
+
+	// sort the unique equations
+	pareto := NewQueueFromArray(temp)
+	pareto.ParetoSort()
+	copy(I.eqns, temp)
+
+}
+
+func (I *Island) breedEqns() {
+	NE := I.params.PopSize
+	for e := 0; e < NE; e++ {
+
+		// select parents with binary tournament
+		rnum1, rnum2, rnum3, rnum4 := I.rng.Intn(NE), I.rng.Intn(NE), I.rng.Intn(NE), I.rng.Intn(NE)
+		if rnum3 < rnum1 {
+			rnum1 = rnum3
+		}
+		if rnum4 < rnum2 {
+			rnum2 = rnum4
+		}
+		if I.eqns[rnum1] == nil || I.eqns[rnum2] == nil {
+			e--
+			continue
+		}
+		p1 := I.eqns[rnum1].eqn
+		p2 := I.eqns[rnum2].eqn
+		if p1 == nil || p2 == nil {
+			e--
+			continue
+		}
+
+		// production of one child from two parents
+		var eqnSimp Expr
+		for {
+			var new_eqn Expr
+
+			// cross equations
+			if I.rng.Float64() < I.prof.CrossRate {
+				new_eqn = CrossEqns_Vanilla(p1, p2, I.treep, I.rng)
+			} else {
+				new_eqn = InjectEqn_Vanilla(p1, I.treep, I.rng)
+			}
+
+			// mutate equation
+			if I.rng.Float64() < I.prof.MutateRate {
+				MutateEqn_Vanilla(new_eqn, I.treep, I.rng)
+			}
+
+			// simplify equation
+			eqnSimp = new_eqn.Simplify(I.treep.SRules)
+			if eqnSimp == nil || !(eqnSimp.HasVar()) {
+				continue
+			}
+			eqnSimp.CalcExprStats()
+
+			I.treep.ResetCurr()
+			I.treep.ResetTemp()
+			if I.treep.CheckExpr(eqnSimp) {
+				break
+			}
+		}
+		I.offs[e] = &Eqn{eqnSimp, complexity(eqnSimp), -1.0, nil}
+	}
+}
+
+func (I *Island) initEqns() {
+	I.eqns = make([]*Eqn, I.params.PopSize)
+	I.offs = make([]*Eqn, I.params.PopSize)
+	for e := 0; e < len(I.offs); e++ {
+		new_eqn := ExprGen(I.treep, I.rng)
+		// fmt.Printf("%d: %v\n", e, new_eqn)
+		I.offs[e] = &Eqn{new_eqn, complexity(new_eqn), -1.0, nil} // -1 because actual errors are >= 0
+	}
+
+}
+
@@ -0,0 +1,219 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// which equations an island sends as emigrants
+type MigSelect int
+
+const (
+	MIG_SEL_BEST       MigSelect = iota // the front of the pareto sorted population
+	MIG_SEL_RANDOM                      // uniformly random members
+	MIG_SEL_TOURNAMENT                  // binary tournaments on pareto rank
+	MIG_SEL_NOVEL                       // members farthest from their nearest neighbor
+)
+
+// which residents an island's immigrants displace
+type MigReplace int
+
+const (
+	MIG_REP_NONE    MigReplace = iota // immigrants just join the selection pool
+	MIG_REP_WORST                     // the tail of the pareto sorted population
+	MIG_REP_RANDOM                    // uniformly random members
+	MIG_REP_SIMILAR                   // the member nearest to each immigrant
+)
+
+func ParseMigSelect(name string) (MigSelect, error) {
+	switch name {
+	case "best":
+		return MIG_SEL_BEST, nil
+	case "random":
+		return MIG_SEL_RANDOM, nil
+	case "tournament":
+		return MIG_SEL_TOURNAMENT, nil
+	case "novel":
+		return MIG_SEL_NOVEL, nil
+	default:
+		return 0, fmt.Errorf("unknown migrant selection %q", name)
+	}
+}
+
+func ParseMigReplace(name string) (MigReplace, error) {
+	switch name {
+	case "none":
+		return MIG_REP_NONE, nil
+	case "worst":
+		return MIG_REP_WORST, nil
+	case "random":
+		return MIG_REP_RANDOM, nil
+	case "similar":
+		return MIG_REP_SIMILAR, nil
+	default:
+		return 0, fmt.Errorf("unknown migrant replacement %q", name)
+	}
+}
+
+// selectEmigrants returns the indices in I.eqns of at most
+// count equations, chosen by the island's selection policy
+func (I *Island) selectEmigrants(count int) []int {
+	live := make([]int, 0, len(I.eqns))
+	for e, eqn := range I.eqns {
+		if eqn != nil {
+			live = append(live, e)
+		}
+	}
+	if count >= len(live) {
+		return live
+	}
+
+	picks := make([]int, 0, count)
+	switch I.params.MigSelect {
+	case MIG_SEL_BEST:
+		picks = append(picks, live[:count]...)
+
+	case MIG_SEL_RANDOM:
+		for _, p := range I.rng.Perm(len(live))[:count] {
+			picks = append(picks, live[p])
+		}
+
+	case MIG_SEL_TOURNAMENT:
+		// lower index is better after the pareto sort
+		for len(picks) < count {
+			r1, r2 := live[I.rng.Intn(len(live))], live[I.rng.Intn(len(live))]
+			if r2 < r1 {
+				r1 = r2
+			}
+			if !hasInt(picks, r1) {
+				picks = append(picks, r1)
+			}
+		}
+
+	case MIG_SEL_NOVEL:
+		sc := newObjScale(I.eqns)
+		novelty := make([]float64, len(live))
+		for i, a := range live {
+			novelty[i] = math.Inf(1)
+			for j, b := range live {
+				if i != j {
+					novelty[i] = math.Min(novelty[i], sc.dist(I.eqns[a], I.eqns[b]))
+				}
+			}
+		}
+		order := make([]int, len(live))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Stable(byScore{order, novelty})
+		for _, o := range order[:count] {
+			picks = append(picks, live[o])
+		}
+	}
+	return picks
+}
+
+// displaceResidents overwrites members of I.eqns with the immigrants
+// according to the island's replacement policy and returns the
+// immigrants which still need to join the selection pool
+func (I *Island) displaceResidents(migs []*Eqn) []*Eqn {
+	if I.params.MigReplace == MIG_REP_NONE {
+		return migs
+	}
+
+	sc := newObjScale(I.eqns)
+	taken := make([]int, 0, len(migs))
+	for m, mig := range migs {
+		if len(taken) == len(I.eqns) {
+			return migs[m:]
+		}
+
+		slot := -1
+		switch I.params.MigReplace {
+		case MIG_REP_WORST:
+			slot = len(I.eqns) - 1 - len(taken)
+
+		case MIG_REP_RANDOM:
+			for slot < 0 || hasInt(taken, slot) {
+				slot = I.rng.Intn(len(I.eqns))
+			}
+
+		case MIG_REP_SIMILAR:
+			best := math.Inf(1)
+			for e, eqn := range I.eqns {
+				if hasInt(taken, e) {
+					continue
+				}
+				// empty slots are the cheapest to fill
+				d := -1.0
+				if eqn != nil {
+					d = sc.dist(mig, eqn)
+				}
+				if slot < 0 || d < best {
+					slot, best = e, d
+				}
+			}
+		}
+
+		I.eqns[slot] = mig
+		taken = append(taken, slot)
+	}
+	return nil
+}
+
+// objScale normalizes the (size, error) objective space
+// so distances between equations weigh both equally
+type objScale struct {
+	size, err float64
+}
+
+func newObjScale(eqns []*Eqn) objScale {
+	var sc objScale
+	for _, e := range eqns {
+		if e == nil {
+			continue
+		}
+		sc.size = math.Max(sc.size, float64(e.size))
+		if !math.IsInf(e.err, 0) {
+			sc.err = math.Max(sc.err, e.err)
+		}
+	}
+	if sc.size == 0.0 {
+		sc.size = 1.0
+	}
+	if sc.err == 0.0 {
+		sc.err = 1.0
+	}
+	return sc
+}
+
+func (sc objScale) dist(a, b *Eqn) float64 {
+	ds := float64(a.size-b.size) / sc.size
+	de := (a.err - b.err) / sc.err
+	return math.Sqrt(ds*ds + de*de)
+}
+
+// sorts indices by decreasing score
+type byScore struct {
+	idx   []int
+	score []float64
+}
+
+func (bs byScore) Len() int           { return len(bs.idx) }
+func (bs byScore) Less(i, j int) bool { return bs.score[bs.idx[i]] > bs.score[bs.idx[j]] }
+func (bs byScore) Swap(i, j int)      { bs.idx[i], bs.idx[j] = bs.idx[j], bs.idx[i] }
+
+// sorts migrants by generation then sender
+type MigrantsArray []*Migrants
+
+func (ma MigrantsArray) Len() int { return len(ma) }
+func (ma MigrantsArray) Less(i, j int) bool {
+	if ma[i].Gen != ma[j].Gen {
+		return ma[i].Gen < ma[j].Gen
+	}
+	return ma[i].From < ma[j].From
+}
+func (ma MigrantsArray) Swap(i, j int) {
+	ma[i], ma[j] = ma[j], ma[i]
+}
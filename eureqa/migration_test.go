@@ -0,0 +1,101 @@
+package eureqa
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// testEqn is an equation with the given size and error
+func testEqn(size int, err float64) *Eqn {
+	e := NewEqn(NewVar(0), err)
+	e.size = size
+	return e
+}
+
+// migIsland holds eqns, pareto sorted, best first
+func migIsland(sel MigSelect, rep MigReplace, eqns ...*Eqn) *Island {
+	p := DefaultParams()
+	p.MigSelect, p.MigReplace = sel, rep
+	return &Island{params: p, eqns: eqns, rng: rand.New(rand.NewSource(1))}
+}
+
+func TestSelectEmigrants(t *testing.T) {
+	eqns := func() []*Eqn {
+		// the last is far from the others in size
+		return []*Eqn{testEqn(1, 1), nil, testEqn(2, 0.9), testEqn(3, 0.85), testEqn(20, 0.1)}
+	}
+	live := []int{0, 2, 3, 4}
+
+	if got := migIsland(MIG_SEL_BEST, MIG_REP_NONE, eqns()...).selectEmigrants(2); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("best picks %v", got)
+	}
+	if got := migIsland(MIG_SEL_NOVEL, MIG_REP_NONE, eqns()...).selectEmigrants(1); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("novel picks %v", got)
+	}
+	for _, sel := range []MigSelect{MIG_SEL_BEST, MIG_SEL_RANDOM, MIG_SEL_TOURNAMENT, MIG_SEL_NOVEL} {
+		I := migIsland(sel, MIG_REP_NONE, eqns()...)
+		if got := I.selectEmigrants(10); !reflect.DeepEqual(got, live) {
+			t.Errorf("selection %d: all of them is %v", sel, got)
+		}
+		for trial := 0; trial < 20; trial++ {
+			got := I.selectEmigrants(3)
+			sort.Ints(got)
+			if len(got) != 3 || got[0] == got[1] || got[1] == got[2] {
+				t.Fatalf("selection %d picks %v", sel, got)
+			}
+			for _, g := range got {
+				if !hasInt(live, g) {
+					t.Fatalf("selection %d picks %v, not all live", sel, got)
+				}
+			}
+		}
+	}
+}
+
+func TestDisplaceResidents(t *testing.T) {
+	eqns := func() []*Eqn {
+		return []*Eqn{testEqn(1, 1), testEqn(5, 0.5), nil, testEqn(10, 0.1)}
+	}
+	migs := []*Eqn{testEqn(10, 0.12), testEqn(4, 0.5)}
+
+	I := migIsland(MIG_SEL_BEST, MIG_REP_NONE, eqns()...)
+	if left := I.displaceResidents(migs); len(left) != 2 || I.eqns[2] != nil {
+		t.Errorf("none displaced residents, %d left", len(left))
+	}
+
+	I = migIsland(MIG_SEL_BEST, MIG_REP_WORST, eqns()...)
+	if left := I.displaceResidents(migs); len(left) != 0 || I.eqns[3] != migs[0] || I.eqns[2] != migs[1] {
+		t.Errorf("worst: %v, %d left", I.eqns, len(left))
+	}
+
+	// the empty slot first, then the nearest resident
+	I = migIsland(MIG_SEL_BEST, MIG_REP_SIMILAR, eqns()...)
+	if left := I.displaceResidents(migs); len(left) != 0 || I.eqns[2] != migs[0] || I.eqns[1] != migs[1] {
+		t.Errorf("similar: %v, %d left", I.eqns, len(left))
+	}
+
+	// more immigrants than slots, the rest join the pool
+	I = migIsland(MIG_SEL_BEST, MIG_REP_RANDOM, eqns()...)
+	many := []*Eqn{testEqn(1, 1), testEqn(2, 1), testEqn(3, 1), testEqn(4, 1), testEqn(5, 1), testEqn(6, 1)}
+	if left := I.displaceResidents(many); len(left) != 2 || left[0] != many[4] {
+		t.Errorf("random: %d left", len(left))
+	}
+	for e, eqn := range I.eqns {
+		if !hasEqn(many[:4], eqn) {
+			t.Errorf("random: slot %d holds %v", e, eqn)
+		}
+	}
+}
+
+func hasEqn(list []*Eqn, e *Eqn) bool {
+	for _, l := range list {
+		if l == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// what to do with the missing cells of a column
+type MissingStrategy int
+
+const (
+	MISS_DROP   MissingStrategy = iota // drop the rows
+	MISS_MEAN                          // the mean of the column
+	MISS_MEDIAN                        // the median of the column
+	MISS_INTERP                        // linear interpolation along the time column
+	MISS_SKIP                          // keep the rows, but evaluation skips them
+)
+
+func ParseMissingStrategy(name string) (MissingStrategy, error) {
+	switch name {
+	case "drop":
+		return MISS_DROP, nil
+	case "mean":
+		return MISS_MEAN, nil
+	case "median":
+		return MISS_MEDIAN, nil
+	case "interp":
+		return MISS_INTERP, nil
+	case "skip":
+		return MISS_SKIP, nil
+	default:
+		return 0, fmt.Errorf("unknown missing value strategy %q", name)
+	}
+}
+
+// MissingPolicy says what to do with the missing cells of each column
+type MissingPolicy struct {
+	Default MissingStrategy
+	Columns map[string]MissingStrategy // by column name, overriding Default
+
+	// the column MISS_INTERP runs along, the row order when empty
+	// and for the time column itself
+	TimeCol string
+}
+
+// cells which are read as missing, besides empty ones and NaN
+var missingTokens = []string{"na", "n/a", "null", "?"}
+
+// nanValue is a NaN written in a data file, a missing value too, but
+// told apart from the tokens so reports can count the rows with NaNs,
+// which can be a sign of a broken export rather than a gap in the data
+var nanValue = math.Float64frombits(0x7ff8000000000bad)
+
+func isNaNValue(v float64) bool {
+	return math.Float64bits(v) == math.Float64bits(nanValue)
+}
+
+func isMissingToken(cell string) bool {
+	cell = strings.ToLower(strings.TrimSpace(cell))
+	if cell == "" {
+		return true
+	}
+	for _, tok := range missingTokens {
+		if cell == tok {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingReport is what FillMissing found and did
+type MissingReport struct {
+	Names   []string // the columns, the inputs, the output, the weights and times
+	Cells   []int    // missing cells of each column
+	Rows    int      // rows with any missing cell
+	NaNRows int      // of Rows, those with a NaN in the file
+	Dropped int      // rows dropped
+	Skipped int      // rows evaluation skips
+}
+
+// Missing is the report of the last FillMissing, nil before one
+func (d *DataSet) Missing() *MissingReport {
+	return d.missing
+}
+
+// Skipped is true for rows evaluation passes over,
+// because of the MISS_SKIP strategy
+func (d *DataSet) Skipped(p int) bool {
+	return d.skip != nil && d.skip[p]
+}
+
+// columns are the inputs, the output, and the weight and time columns
+// when there are, so column Dimensions() is the output
+func (d *DataSet) columns() int {
+	return len(d.colNames())
+}
+
+func (d *DataSet) colNames() []string {
+	names := append(append([]string{}, d.var_names...), d.out_name)
+	if d.weights != nil {
+		names = append(names, d.weight_name)
+	}
+	if d.times != nil {
+		names = append(names, d.time_name)
+	}
+	return names
+}
+
+func (d *DataSet) colName(c int) string {
+	return d.colNames()[c]
+}
+
+func (d *DataSet) colIndex(name string) int {
+	for c, n := range d.colNames() {
+		if n == name {
+			return c
+		}
+	}
+	return -1
+}
+
+func (d *DataSet) cellPtr(p, c int) *float64 {
+	nv := len(d.var_names)
+	switch {
+	case c < nv:
+		return &d.cols[c][p]
+	case c == nv:
+		return &d.output[p]
+	case c == nv+1 && d.weights != nil:
+		return &d.weights[p]
+	}
+	return &d.times[p]
+}
+
+func (d *DataSet) cell(p, c int) float64         { return *d.cellPtr(p, c) }
+func (d *DataSet) setCell(p, c int, val float64) { *d.cellPtr(p, c) = val }
+
+// FillMissing applies the policy to the missing cells, the NaN ones, and
+// reports what it did. Rows are dropped first, the time column is filled
+// before the columns interpolated along it, and the rows left missing,
+// under MISS_SKIP, are marked for evaluation to skip.
+func (d *DataSet) FillMissing(mp *MissingPolicy) (*MissingReport, error) {
+	strats := make([]MissingStrategy, d.columns())
+	for c := range strats {
+		strats[c] = mp.Default
+	}
+	for name, st := range mp.Columns {
+		c := d.colIndex(name)
+		if c < 0 {
+			return nil, fmt.Errorf("missing value strategy for unknown column %q", name)
+		}
+		strats[c] = st
+	}
+	timeCol := -1
+	if mp.TimeCol != "" {
+		if timeCol = d.colIndex(mp.TimeCol); timeCol < 0 {
+			return nil, fmt.Errorf("unknown time column %q", mp.TimeCol)
+		}
+	}
+
+	rpt := &MissingReport{Names: d.colNames(), Cells: make([]int, len(strats))}
+	dropped := make([]bool, d.Length())
+	for p := range dropped {
+		missing, nan := false, false
+		for c, st := range strats {
+			if v := d.cell(p, c); math.IsNaN(v) {
+				rpt.Cells[c]++
+				missing = true
+				nan = nan || isNaNValue(v)
+				dropped[p] = dropped[p] || st == MISS_DROP
+			}
+		}
+		if missing {
+			rpt.Rows++
+		}
+		if nan {
+			rpt.NaNRows++
+		}
+		if dropped[p] {
+			rpt.Dropped++
+		}
+	}
+	d.keepRows(dropped)
+
+	// the time column goes first, others interpolate along it
+	order := make([]int, 0, len(strats))
+	if timeCol >= 0 {
+		order = append(order, timeCol)
+	}
+	for c := range strats {
+		if c != timeCol {
+			order = append(order, c)
+		}
+	}
+	for _, c := range order {
+		switch strats[c] {
+		case MISS_MEAN, MISS_MEDIAN:
+			d.impute(c, strats[c])
+		case MISS_INTERP:
+			along := timeCol
+			if c == timeCol {
+				along = -1 // the time column itself goes by the rows
+			}
+			if err := d.interpolate(c, along); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	d.skip = nil
+	for p := 0; p < d.Length(); p++ {
+		for c := range strats {
+			if math.IsNaN(d.cell(p, c)) {
+				if d.skip == nil {
+					d.skip = make([]bool, d.Length())
+				}
+				d.skip[p] = true
+			}
+		}
+		if d.Skipped(p) {
+			rpt.Skipped++
+		}
+	}
+	if d.Length() == rpt.Skipped {
+		return nil, fmt.Errorf("no samples left without missing values")
+	}
+	d.missing = rpt
+	return rpt, nil
+}
+
+// keepRows removes the rows where drop is true
+func (d *DataSet) keepRows(drop []bool) {
+	cols := append([][]float64{d.output, d.weights, d.times}, d.cols...)
+	n := 0
+	for p := range d.output {
+		if drop[p] {
+			continue
+		}
+		for _, col := range cols {
+			if col != nil {
+				col[n] = col[p]
+			}
+		}
+		if d.exp != nil {
+			d.exp[n] = d.exp[p]
+		}
+		if d.skip != nil {
+			d.skip[n] = d.skip[p]
+		}
+		n++
+	}
+	for c := range d.cols {
+		d.cols[c] = d.cols[c][:n]
+	}
+	d.output = d.output[:n]
+	if d.weights != nil {
+		d.weights = d.weights[:n]
+	}
+	if d.times != nil {
+		d.times = d.times[:n]
+	}
+	if d.exp != nil {
+		d.exp = d.exp[:n]
+	}
+	if d.skip != nil {
+		d.skip = d.skip[:n]
+	}
+}
+
+// impute sets the missing cells of column c to the mean
+// or median of the present ones
+func (d *DataSet) impute(c int, st MissingStrategy) {
+	var present []float64
+	for p := 0; p < d.Length(); p++ {
+		if v := d.cell(p, c); !math.IsNaN(v) {
+			present = append(present, v)
+		}
+	}
+	if len(present) == 0 {
+		return // nothing to impute from, left for evaluation to skip
+	}
+
+	fill := 0.0
+	if st == MISS_MEAN {
+		for _, v := range present {
+			fill += v
+		}
+		fill /= float64(len(present))
+	} else {
+		sort.Float64s(present)
+		n := len(present)
+		fill = (present[(n-1)/2] + present[n/2]) / 2
+	}
+
+	for p := 0; p < d.Length(); p++ {
+		if math.IsNaN(d.cell(p, c)) {
+			d.setCell(p, c, fill)
+			d.imputed = true
+		}
+	}
+}
+
+// interpolate fills the missing cells of column c linearly between the
+// nearest present ones in order of the time column, or of the rows when
+// it is -1, holding the first and last present values beyond them
+func (d *DataSet) interpolate(c, timeCol int) error {
+	order := make([]int, d.Length())
+	times := make([]float64, d.Length())
+	for p := range order {
+		order[p] = p
+		times[p] = float64(p)
+		if timeCol >= 0 {
+			if times[p] = d.cell(p, timeCol); math.IsNaN(times[p]) {
+				return fmt.Errorf("can't interpolate %s, the time column %s has missing values left",
+					d.colName(c), d.colName(timeCol))
+			}
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return times[order[i]] < times[order[j]] })
+
+	// the next present row after each position in time order
+	nexts := make([]int, len(order))
+	for i, n := len(order)-1, -1; i >= 0; i-- {
+		nexts[i] = n
+		if !math.IsNaN(d.cell(order[i], c)) {
+			n = order[i]
+		}
+	}
+
+	prev := -1 // last present row in time order
+	for i, p := range order {
+		if !math.IsNaN(d.cell(p, c)) {
+			prev = p
+			continue
+		}
+		next := nexts[i]
+
+		switch {
+		case prev < 0 && next < 0:
+			return nil // nothing to interpolate from
+		case prev < 0:
+			d.setCell(p, c, d.cell(next, c))
+		case next < 0:
+			d.setCell(p, c, d.cell(prev, c))
+		case times[next] == times[prev]:
+			d.setCell(p, c, (d.cell(prev, c)+d.cell(next, c))/2)
+		default:
+			t := (times[p] - times[prev]) / (times[next] - times[prev])
+			d.setCell(p, c, d.cell(prev, c)+t*(d.cell(next, c)-d.cell(prev, c)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package eureqa
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFillMissing(t *testing.T) {
+	// t is the time, x has gaps at rows 1 and 3, y is the output
+	const text = "t x y\n0 1 1\n1 NA 2\n2 3 3\n3 NA 4\n10 10 5\n"
+	tests := []struct {
+		name   string
+		policy *MissingPolicy
+		x      []float64
+		skip   int
+	}{
+		{"drop", &MissingPolicy{Default: MISS_DROP}, []float64{1, 3, 10}, 0},
+		{"mean", &MissingPolicy{Default: MISS_MEAN}, []float64{1, 14.0 / 3, 3, 14.0 / 3, 10}, 0},
+		{"median", &MissingPolicy{Default: MISS_MEDIAN}, []float64{1, 3, 3, 3, 10}, 0},
+		{"interp rows", &MissingPolicy{Default: MISS_INTERP}, []float64{1, 2, 3, 6.5, 10}, 0},
+		{"interp time", &MissingPolicy{Default: MISS_INTERP, TimeCol: "t"}, []float64{1, 2, 3, 3.875, 10}, 0},
+		{"skip", &MissingPolicy{Default: MISS_SKIP}, []float64{1, math.NaN(), 3, math.NaN(), 10}, 2},
+		{"by column", &MissingPolicy{Default: MISS_DROP, Columns: map[string]MissingStrategy{"x": MISS_MEDIAN}},
+			[]float64{1, 3, 3, 3, 10}, 0},
+	}
+	for _, tt := range tests {
+		d, err := readString(t, text, &ReadOptions{Missing: tt.policy})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		x := d.Column(1)
+		if !sameFloats(x, tt.x) {
+			t.Errorf("%s: x is %v, want %v", tt.name, x, tt.x)
+		}
+		mr := d.Missing()
+		if mr.Rows != 2 || mr.Cells[1] != 2 || mr.Skipped != tt.skip {
+			t.Errorf("%s: report %+v", tt.name, mr)
+		}
+		for p := 0; p < d.Length(); p++ {
+			if d.Skipped(p) != math.IsNaN(tt.x[p]) {
+				t.Errorf("%s: row %d skipped %v", tt.name, p, d.Skipped(p))
+			}
+		}
+	}
+}
+
+func TestFillMissingEdges(t *testing.T) {
+	// gaps before the first and after the last value are held
+	d, err := readString(t, "x y\nNA 1\n2 2\nNA 3\n4 4\nNA 5\nNA 6\n",
+		&ReadOptions{Missing: &MissingPolicy{Default: MISS_INTERP}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := d.Column(0); !sameFloats(x, []float64{2, 2, 3, 4, 4, 4}) {
+		t.Errorf("x is %v", x)
+	}
+
+	bad := []*MissingPolicy{
+		{Columns: map[string]MissingStrategy{"nope": MISS_MEAN}},
+		{Default: MISS_INTERP, TimeCol: "nope"},
+		{Default: MISS_SKIP, Columns: map[string]MissingStrategy{"x": MISS_SKIP}},
+	}
+	for _, mp := range bad {
+		if _, err := readString(t, "x y\nNA 1\nNA 2\n", &ReadOptions{Missing: mp}); err == nil {
+			t.Errorf("no error for %+v", mp)
+		}
+	}
+}
+
+// sameFloats compares values, with NaNs equal
+func sameFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			if !math.IsNaN(a[i]) || !math.IsNaN(b[i]) {
+				return false
+			}
+		} else if math.Abs(a[i]-b[i]) > 1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseMissingStrategy(t *testing.T) {
+	var got []MissingStrategy
+	for _, name := range []string{"drop", "mean", "median", "interp", "skip"} {
+		ms, err := ParseMissingStrategy(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ms)
+	}
+	if want := []MissingStrategy{MISS_DROP, MISS_MEAN, MISS_MEDIAN, MISS_INTERP, MISS_SKIP}; !reflect.DeepEqual(got, want) {
+		t.Errorf("strategies %v, want %v", got, want)
+	}
+	if _, err := ParseMissingStrategy("zero"); err == nil {
+		t.Error("no error for an unknown strategy")
+	}
+}
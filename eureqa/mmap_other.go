@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package eureqa
+
+import (
+	"io/ioutil"
+)
+
+// mapFile reads the whole file, where there is no mmap
+func mapFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+func unmapFile(b []byte) error { return nil }
@@ -0,0 +1,186 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// how a column is scaled for the search
+type Scaling int
+
+const (
+	SCALE_NONE     Scaling = iota
+	SCALE_STANDARD         // to mean 0 and standard deviation 1
+	SCALE_MINMAX           // to the range [0,1]
+)
+
+func ParseScaling(name string) (Scaling, error) {
+	switch name {
+	case "none":
+		return SCALE_NONE, nil
+	case "standard":
+		return SCALE_STANDARD, nil
+	case "minmax":
+		return SCALE_MINMAX, nil
+	default:
+		return 0, fmt.Errorf("unknown scaling %q", name)
+	}
+}
+
+// ScalePolicy says how to scale the inputs and the output
+type ScalePolicy struct {
+	Default Scaling
+	Columns map[string]Scaling // by column name, overriding Default
+}
+
+// Scaler holds the scaling Normalize applied, x' = (x - off) / scale
+// for each input and the output, to take equations back to the
+// original units
+type Scaler struct {
+	inOff, inScale   []float64
+	outOff, outScale float64
+}
+
+// Normalize returns a copy of d with its inputs and output scaled, so
+// equations with constants of ordinary size fit data of any scale, and
+// the Scaler to take the equations found back to the units of d. The
+// statistics leave out rows skipped for missing values.
+func (d *DataSet) Normalize(sp *ScalePolicy) (*DataSet, *Scaler, error) {
+	nv := len(d.var_names)
+	scalings := make([]Scaling, nv+1)
+	for c := range scalings {
+		scalings[c] = sp.Default
+	}
+	for name, sc := range sp.Columns {
+		c := d.colIndex(name)
+		if c < 0 || c > nv {
+			return nil, nil, fmt.Errorf("scaling for unknown input or output column %q", name)
+		}
+		scalings[c] = sc
+	}
+
+	offs, scales := make([]float64, nv+1), make([]float64, nv+1)
+	for c, sc := range scalings {
+		offs[c], scales[c] = d.scaleOf(c, sc)
+	}
+
+	n := *d
+	n.cols = make([][]float64, nv)
+	for c, col := range d.cols {
+		n.cols[c] = make([]float64, len(col))
+		for p, x := range col {
+			n.cols[c][p] = (x - offs[c]) / scales[c]
+		}
+	}
+	n.output = make([]float64, len(d.output))
+	for p, y := range d.output {
+		n.output[p] = (y - offs[nv]) / scales[nv]
+	}
+	return &n, &Scaler{offs[:nv], scales[:nv], offs[nv], scales[nv]}, nil
+}
+
+// scaleOf is the offset and scale of column c, 0 and 1 for
+// SCALE_NONE, and a scale of 1 for a constant column
+func (d *DataSet) scaleOf(c int, sc Scaling) (off, scale float64) {
+	if sc == SCALE_NONE {
+		return 0, 1
+	}
+	sum, sumSq, n := 0.0, 0.0, 0
+	min, max := math.Inf(1), math.Inf(-1)
+	for p := 0; p < d.Length(); p++ {
+		x := d.cell(p, c)
+		if d.Skipped(p) || math.IsNaN(x) {
+			continue
+		}
+		sum += x
+		sumSq += x * x
+		min, max = math.Min(min, x), math.Max(max, x)
+		n++
+	}
+	if n == 0 {
+		return 0, 1
+	}
+
+	if sc == SCALE_MINMAX {
+		off, scale = min, max-min
+	} else {
+		off = sum / float64(n)
+		scale = math.Sqrt(math.Max(sumSq/float64(n)-off*off, 0))
+	}
+	if scale == 0 || math.IsInf(scale, 0) || math.IsNaN(scale) {
+		scale = 1
+	}
+	return off, scale
+}
+
+// UnscaleExpr rewrites an equation found on the scaled data into one on
+// the original units, putting (x - off) / scale in place of each input
+// and scaling the result back
+func (sc *Scaler) UnscaleExpr(e Expr) Expr {
+	e = e.Clone()
+	e.CalcExprStats()
+
+	// replacing from the last node back leaves the
+	// positions of the nodes still to visit as they were
+	for pos := e.Size() - 1; pos >= 0; pos-- {
+		at := pos
+		v, ok := e.GetExpr(&at).(*Var)
+		if !ok || sc.inOff[v.P] == 0 && sc.inScale[v.P] == 1 {
+			continue
+		}
+		var x Expr = NewVar(v.P)
+		if sc.inOff[v.P] != 0 {
+			add := NewAdd()
+			add.Insert(x)
+			add.Insert(NewConstantF(-sc.inOff[v.P]))
+			x = add
+		}
+		if sc.inScale[v.P] != 1 {
+			x = NewDiv(x, NewConstantF(sc.inScale[v.P]))
+		}
+		if pos == 0 {
+			e = x // SwapExpr can't replace the root
+		} else {
+			SwapExpr(e, x, pos)
+		}
+	}
+
+	if sc.outScale != 1 {
+		mul := NewMul()
+		mul.Insert(NewConstantF(sc.outScale))
+		mul.Insert(e)
+		e = mul
+	}
+	if sc.outOff != 0 {
+		add := NewAdd()
+		add.Insert(e)
+		add.Insert(NewConstantF(sc.outOff))
+		e = add
+	}
+	e.CalcExprStats()
+	return e
+}
+
+// Unscale rewrites the front of a search on the scaled data into the
+// original units and scores it on data, the original DataSet, with f,
+// the search's Fitness, or MAE when nil. The models keep the size they
+// had in the search, and equations f rejects in the original units,
+// as by overflowing, are dropped.
+func (sc *Scaler) Unscale(res *Result, data *DataSet, f Fitness) *Result {
+	if f == nil {
+		f = MAE{}
+	}
+	r := &Result{Seed: res.Seed, Cancelled: res.Cancelled, names: data.VarNames(), sysNames: data.SysNames()}
+	for _, m := range res.Front {
+		e := &Eqn{eqn: sc.UnscaleExpr(m.eqn), size: m.size}
+		objs, ok := f.Objectives(e, data)
+		if !ok || len(objs) == 0 {
+			continue
+		}
+		e.err, e.objs = objs[0], objs
+		r.Front = append(r.Front, newModel(e, data))
+	}
+	return r
+}
@@ -0,0 +1,105 @@
+package eureqa
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+func TestNormalizeRoundTrip(t *testing.T) {
+	// y = 3x + 5, and z is the same on every row
+	const text = "x z y\n-2 7 -1\n0 7 5\n1 7 8\n5 7 20\n"
+	for _, sc := range []Scaling{SCALE_STANDARD, SCALE_MINMAX} {
+		d, err := readString(t, text, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, scaler, err := d.Normalize(&ScalePolicy{Default: sc})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the scaled columns, z only moved to 0
+		x, y := n.Column(0), make([]float64, n.Length())
+		for p := range y {
+			y[p] = n.Output(p)
+		}
+		lo, hi, mean, sd := stats(x)
+		if sc == SCALE_STANDARD && (math.Abs(mean) > 1e-12 || math.Abs(sd-1) > 1e-12) ||
+			sc == SCALE_MINMAX && (lo != 0 || hi != 1) {
+			t.Errorf("scaling %d: x scaled to %v", sc, x)
+		}
+		if !sameFloats(x, y) {
+			t.Errorf("scaling %d: x %v and y %v, scaled, differ", sc, x, y)
+		}
+		if z := n.Column(1); !sameFloats(z, []float64{0, 0, 0, 0}) {
+			t.Errorf("scaling %d: constant z scaled to %v", sc, z)
+		}
+		if d.Column(0)[0] != -2 || d.Output(0) != -1 {
+			t.Errorf("scaling %d: Normalize changed the original data", sc)
+		}
+
+		// on the original data, an unscaled equation is the scaled output of
+		// the equation on the scaled data, scaled back
+		mul := NewMul()
+		mul.Insert(NewVar(0))
+		mul.Insert(NewVar(1))
+		add := NewAdd()
+		add.Insert(mul)
+		add.Insert(NewCos(NewVar(0)))
+		add.Insert(NewConstantF(0.5))
+		var e Expr = add
+		ue := scaler.UnscaleExpr(e)
+		for p := 0; p < d.Length(); p++ {
+			want := scaler.outOff + scaler.outScale*e.Eval(0, n.Input(p), nil, nil)
+			if got := ue.Eval(0, d.Input(p), nil, nil); math.Abs(got-want) > 1e-9 {
+				t.Errorf("scaling %d, row %d: unscaled %v is %g, want %g", sc, p, ue, got, want)
+			}
+		}
+
+		// y' = x' fits exactly, and so does its unscaled form
+		res := &Result{Seed: 3, Front: []*Model{newModel(&Eqn{eqn: NewVar(0), size: 1}, n)}}
+		un := scaler.Unscale(res, d, nil)
+		if len(un.Front) != 1 || un.Seed != 3 || un.Front[0].Size() != 1 || un.Front[0].Err() > 1e-9 {
+			t.Errorf("scaling %d: unscaled front %+v", sc, un.Front)
+		}
+	}
+}
+
+func TestNormalizeColumns(t *testing.T) {
+	d, err := readString(t, "x z y\n0 1 1\n2 3 5\n4 5 9\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, scaler, err := d.Normalize(&ScalePolicy{Columns: map[string]Scaling{"z": SCALE_MINMAX}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameFloats(n.Column(0), d.Column(0)) || !sameFloats(n.Column(1), []float64{0, 0.5, 1}) || n.Output(2) != 9 {
+		t.Errorf("scaled only z: %v %v %g", n.Column(0), n.Column(1), n.Output(2))
+	}
+	// the output isn't scaled, so neither is the equation's result
+	if ue := scaler.UnscaleExpr(NewVar(0)); ue.ExprType() != VAR {
+		t.Errorf("x unscaled to %v", ue)
+	}
+
+	if _, _, err := d.Normalize(&ScalePolicy{Columns: map[string]Scaling{"q": SCALE_STANDARD}}); err == nil {
+		t.Error("no error scaling an unknown column")
+	}
+}
+
+// stats are the least, greatest, mean and population
+// standard deviation of xs
+func stats(xs []float64) (lo, hi, mean, sd float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, x := range xs {
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+		mean += x
+	}
+	mean /= float64(len(xs))
+	for _, x := range xs {
+		sd += (x - mean) * (x - mean)
+	}
+	return lo, hi, mean, math.Sqrt(sd / float64(len(xs)))
+}
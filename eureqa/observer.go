@@ -0,0 +1,190 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+)
+
+// Observer follows a running search. Every call is made from the
+// Search goroutine, so observers need no locking of their own, but
+// they should return quickly as the search waits on them.
+type Observer interface {
+	// the search is initializing its islands
+	OnSearchInit(islands int)
+
+	// an island was initialized, with the name of its profile
+	OnIslandStart(isle int, profile string)
+
+	// the islands are initialized and about to run
+	OnSearchStart()
+
+	// an island finished a generation
+	OnGenerationEnd(isle, gen int, stats *GenStats)
+
+	// e, reported by isle, joined the front of the whole search
+	OnNewParetoMember(isle, gen int, e *Eqn)
+
+	// an island sent migrants to a neighbor
+	OnMigration(m *Migration)
+
+	// the Search reseeded a stagnant island
+	OnIslandRestart(isle, gen int, stats *GenStats)
+
+	// an island stopped, eqns are its final equations
+	OnIslandStop(isle int, eqns []*Eqn)
+
+	// the search stopped, front is the merged final reports
+	OnSearchDone(front []*Eqn, cancelled bool)
+}
+
+// NopObserver ignores every event, observers embed
+// it to implement only the events they follow
+type NopObserver struct{}
+
+func (NopObserver) OnSearchInit(islands int)                       {}
+func (NopObserver) OnIslandStart(isle int, profile string)         {}
+func (NopObserver) OnSearchStart()                                 {}
+func (NopObserver) OnGenerationEnd(isle, gen int, stats *GenStats) {}
+func (NopObserver) OnNewParetoMember(isle, gen int, e *Eqn)        {}
+func (NopObserver) OnMigration(m *Migration)                       {}
+func (NopObserver) OnIslandRestart(isle, gen int, stats *GenStats) {}
+func (NopObserver) OnIslandStop(isle int, eqns []*Eqn)             {}
+func (NopObserver) OnSearchDone(front []*Eqn, cancelled bool)      {}
+
+// GenStats describes an island at the end of a generation
+type GenStats struct {
+	BestErr   float64 // of the reported equations
+	BestSize  int     // size of the equation with BestErr
+	FrontSize int     // reported equations which aren't nil
+
+	Hypervol float64 // of the reported front
+	Unique   int     // distinct equation structures the island has reported
+	LastGain int     // generation the island last improved in
+
+	Emigrants  int // equations sent to neighbors
+	Immigrants int // equations merged from other islands
+}
+
+func (S *Search) addObserver(o Observer) {
+	S.observers = append(S.observers, o)
+}
+
+// observeReport updates the island's progress and the search front
+// with a report and tells the observers, then checks for stagnation
+func (S *Search) observeReport(rpt *IslandReport) {
+	ip := S.progress[rpt.Id]
+	ip.update(rpt.Eqns, rpt.Gen, S.params.Tree.MaxSize)
+	stats := S.genStats(rpt)
+
+	for _, e := range rpt.Eqns {
+		if e != nil && S.addToFront(e) {
+			for _, o := range S.observers {
+				o.OnNewParetoMember(rpt.Id, rpt.Gen, e)
+			}
+		}
+	}
+	for _, m := range rpt.Sent {
+		for _, o := range S.observers {
+			o.OnMigration(m)
+		}
+	}
+	for _, o := range S.observers {
+		o.OnGenerationEnd(rpt.Id, rpt.Gen, stats)
+	}
+
+	S.checkStagnation(rpt.Id, rpt.Gen, stats)
+}
+
+func (S *Search) genStats(rpt *IslandReport) *GenStats {
+	ip := S.progress[rpt.Id]
+	stats := &GenStats{
+		BestErr:    math.Inf(1),
+		Hypervol:   ip.hypervol,
+		Unique:     len(ip.unique),
+		LastGain:   ip.lastGain,
+		Immigrants: rpt.Recvd,
+	}
+	for _, e := range rpt.Eqns {
+		if e == nil {
+			continue
+		}
+		stats.FrontSize++
+		if e.err < stats.BestErr {
+			stats.BestErr = e.err
+			stats.BestSize = e.size
+		}
+	}
+	for _, m := range rpt.Sent {
+		stats.Emigrants += m.Count
+	}
+	return stats
+}
+
+// consoleObserver prints the progress of a search to stdout,
+// it is what the command line tool shows
+type consoleObserver struct {
+	gens []int // generations completed by each island
+	next int   // the next generation to print
+}
+
+func NewConsoleObserver(islands int) Observer {
+	return &consoleObserver{gens: make([]int, islands)}
+}
+
+func (c *consoleObserver) OnSearchInit(islands int) {
+	fmt.Println("Initializing Search")
+}
+
+func (c *consoleObserver) OnIslandStart(isle int, profile string) {
+	fmt.Println("Initializing Island", isle, "profile:", profile)
+}
+
+func (c *consoleObserver) OnSearchStart() {
+	fmt.Println("Search Initialized")
+	fmt.Println()
+	fmt.Println("Running Search\n-------------------")
+	fmt.Println()
+}
+
+// OnGenerationEnd prints a search generation once every island is past it
+func (c *consoleObserver) OnGenerationEnd(isle, gen int, stats *GenStats) {
+	if gen >= c.gens[isle] {
+		c.gens[isle] = gen + 1
+	}
+	min := c.gens[0]
+	for _, g := range c.gens {
+		if g < min {
+			min = g
+		}
+	}
+	for ; c.next < min; c.next++ {
+		fmt.Printf("Gen %3d:\n", c.next)
+	}
+}
+
+func (c *consoleObserver) OnNewParetoMember(isle, gen int, e *Eqn) {}
+
+func (c *consoleObserver) OnMigration(m *Migration) {}
+
+func (c *consoleObserver) OnIslandRestart(isle, gen int, stats *GenStats) {
+	fmt.Printf("Restarting Island %d at gen %d: no improvement in %d generations, %d equation structures seen\n",
+		isle, gen, gen-stats.LastGain, stats.Unique)
+}
+
+// OnIslandStop prints the island's best equations
+func (c *consoleObserver) OnIslandStop(isle int, eqns []*Eqn) {
+	fmt.Println("Isle ", isle)
+	for e := 0; e < 10 && e < len(eqns); e++ {
+		fmt.Print(eqns[e])
+	}
+	fmt.Println()
+}
+
+func (c *consoleObserver) OnSearchDone(front []*Eqn, cancelled bool) {
+	if cancelled {
+		fmt.Println("Search Cancelled")
+	} else {
+		fmt.Println("Maximum Generations Reached")
+	}
+	fmt.Println()
+}
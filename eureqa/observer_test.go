@@ -0,0 +1,96 @@
+package eureqa
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recorder notes the lifecycle events of a search in order
+type recorder struct {
+	NopObserver
+	events []string
+}
+
+func (r *recorder) OnSearchInit(islands int) {
+	r.events = append(r.events, fmt.Sprintf("init %d", islands))
+}
+func (r *recorder) OnIslandStart(isle int, profile string) {
+	r.events = append(r.events, fmt.Sprintf("island %d start", isle))
+}
+func (r *recorder) OnSearchStart() {
+	r.events = append(r.events, "start")
+}
+func (r *recorder) OnGenerationEnd(isle, gen int, stats *GenStats) {
+	r.events = append(r.events, fmt.Sprintf("island %d gen %d", isle, gen))
+}
+func (r *recorder) OnIslandStop(isle int, eqns []*Eqn) {
+	r.events = append(r.events, fmt.Sprintf("island %d stop", isle))
+}
+func (r *recorder) OnSearchDone(front []*Eqn, cancelled bool) {
+	r.events = append(r.events, fmt.Sprintf("done %v", cancelled))
+}
+
+func TestObserverOrder(t *testing.T) {
+	rec := new(recorder)
+	_, err := NewSearch(quadData(), WithSeed(1), WithGens(3), WithIslands(2, 20),
+		WithLockstep(), WithObserver(rec)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"init 2",
+		"island 0 start",
+		"island 1 start",
+		"start",
+		"island 0 gen 0", "island 1 gen 0",
+		"island 0 gen 1", "island 1 gen 1",
+		"island 0 gen 2", "island 1 gen 2",
+		"island 0 stop",
+		"island 1 stop",
+		"done false",
+	}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("events\n%q\nwant\n%q", rec.events, want)
+	}
+}
+
+func TestObserverStatsEveryGeneration(t *testing.T) {
+	// free running islands report in any order, but each island
+	// reports its generations in order, between start and stop
+	rec := new(recorder)
+	_, err := NewSearch(quadData(), WithSeed(1), WithGens(5), WithIslands(3, 20),
+		WithObserver(rec)).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := make([]int, 3)
+	started, stopped := false, 0
+	for _, ev := range rec.events {
+		var isle, gen int
+		switch {
+		case ev == "start":
+			started = true
+		case ev == fmt.Sprintf("island %d stop", stopped):
+			stopped++
+		}
+		if n, _ := fmt.Sscanf(ev, "island %d gen %d", &isle, &gen); n == 2 {
+			if !started || stopped > 0 {
+				t.Errorf("%q outside the run", ev)
+			}
+			if gen != next[isle] {
+				t.Errorf("%q, want gen %d", ev, next[isle])
+			}
+			next[isle]++
+		}
+	}
+	for i, n := range next {
+		if n < 5 {
+			t.Errorf("island %d reported %d generations, want at least 5", i, n)
+		}
+	}
+	if stopped != 3 || rec.events[len(rec.events)-1] != "done false" {
+		t.Errorf("events end %q", rec.events[len(rec.events)-3:])
+	}
+}
@@ -0,0 +1,195 @@
+package eureqa
+
+import (
+	"fmt"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// Option changes the settings of a new Search
+type Option func(*Search)
+
+// DefaultParams are the settings a Search starts with
+func DefaultParams() *Params {
+
+	var srp Params
+	srp.Gens = 100
+	srp.Islands = 8
+
+	srp.PopSize = 50
+	srp.RptSize = 10
+	srp.CrossRate = 0.75
+	srp.MutateRate = 0.2
+	srp.EvalWorkers = 1
+	srp.Fitness = MAE{}
+
+	srp.StagWindow = 0 // restarting stagnant islands is opt in
+	srp.RestartElites = 2
+	srp.RestartImmigrants = 2
+
+	srp.Topology = "ring"
+	srp.MigEpoch = 5
+	srp.MigCount = 2
+	srp.MigSelect = MIG_SEL_BEST
+	srp.MigReplace = MIG_REP_NONE
+
+	p := &srp.Tree
+	p.MaxSize = 50
+	p.MinSize = 3
+	p.MaxDepth = 6
+	p.MinDepth = 1
+
+	p.RootsT = []ExprType{ADD, MUL}
+	p.NodesT = []ExprType{VAR, CONSTANTF, ADD, NEG, MUL, DIV, COS, SIN}
+	p.NonTrigT = []ExprType{VAR, CONSTANTF, ADD, NEG, MUL, DIV}
+	p.LeafsT = []ExprType{VAR, CONSTANTF}
+
+	p.DoSimp = true
+	p.SRules = DefaultRules()
+
+	return &srp
+}
+
+// Validate checks the settings before a Search starts,
+// so bad ones are reported rather than failing mid run
+func (p *Params) Validate() error {
+	if p.Gens < 0 {
+		return fmt.Errorf("%d generations", p.Gens)
+	}
+	if p.Islands < 1 {
+		return fmt.Errorf("%d islands, there must be at least 1", p.Islands)
+	}
+	if p.PopSize < 1 {
+		return fmt.Errorf("population of %d, it must be at least 1", p.PopSize)
+	}
+	if p.RptSize < 1 || p.RptSize > p.PopSize {
+		return fmt.Errorf("report size %d, it must be between 1 and the population, %d", p.RptSize, p.PopSize)
+	}
+	if p.EvalWorkers < 1 {
+		return fmt.Errorf("%d evaluation workers, there must be at least 1", p.EvalWorkers)
+	}
+	if err := checkRates(p.CrossRate, p.MutateRate); err != nil {
+		return err
+	}
+	if err := p.Tree.validate(); err != nil {
+		return err
+	}
+	for _, prof := range p.Profiles {
+		if prof == nil || prof.Tree == nil {
+			return fmt.Errorf("profile without tree settings")
+		}
+		if err := checkRates(prof.CrossRate, prof.MutateRate); err != nil {
+			return fmt.Errorf("profile %s: %v", prof.Name, err)
+		}
+		if err := prof.Tree.validate(); err != nil {
+			return fmt.Errorf("profile %s: %v", prof.Name, err)
+		}
+	}
+
+	if p.StagWindow < 0 || p.RestartElites < 0 || p.RestartImmigrants < 0 {
+		return fmt.Errorf("negative restart settings")
+	}
+	if _, err := newTopology(p.Topology, p.Islands, 0); err != nil {
+		return err
+	}
+	if p.MigEpoch < 0 || p.MigInterval < 0 || p.MigCount < 0 {
+		return fmt.Errorf("negative migration settings")
+	}
+	if p.MigSelect < MIG_SEL_BEST || p.MigSelect > MIG_SEL_NOVEL {
+		return fmt.Errorf("unknown migrant selection %d", p.MigSelect)
+	}
+	if p.MigReplace < MIG_REP_NONE || p.MigReplace > MIG_REP_SIMILAR {
+		return fmt.Errorf("unknown migrant replacement %d", p.MigReplace)
+	}
+	return nil
+}
+
+func checkRates(cross, mutate float64) error {
+	if !(cross >= 0 && cross <= 1) || !(mutate >= 0 && mutate <= 1) {
+		return fmt.Errorf("crossover rate %g and mutation rate %g, they must be in [0,1]", cross, mutate)
+	}
+	return nil
+}
+
+func (tp *TreeParams) validate() error {
+	if tp.MinSize < 1 || tp.MaxSize < tp.MinSize {
+		return fmt.Errorf("equation size limits %d:%d", tp.MinSize, tp.MaxSize)
+	}
+	if tp.MinDepth < 0 || tp.MaxDepth < tp.MinDepth {
+		return fmt.Errorf("equation depth limits %d:%d", tp.MinDepth, tp.MaxDepth)
+	}
+	if len(tp.RootsT) == 0 || len(tp.NodesT) == 0 || len(tp.LeafsT) == 0 {
+		return fmt.Errorf("no root, node or leaf types to make equations of")
+	}
+	return nil
+}
+
+// Clone copies the settings, with their own tree settings and profiles
+func (p *Params) Clone() *Params {
+	c := *p
+	c.Tree = *p.Tree.Clone()
+	if p.Profiles != nil {
+		c.Profiles = make([]*IslandProfile, len(p.Profiles))
+		for i, prof := range p.Profiles {
+			if prof != nil {
+				c.Profiles[i] = prof.Clone()
+			}
+		}
+	}
+	return &c
+}
+
+// WithParams replaces all of the settings with a copy of p, so it
+// should come before any options which change single settings
+func WithParams(p *Params) Option {
+	return func(S *Search) { S.params = p.Clone() }
+}
+
+// WithSeed sets the master seed, 0 picks one from the clock
+func WithSeed(seed int64) Option {
+	return func(S *Search) { S.params.Seed = seed }
+}
+
+func WithGens(gens int) Option {
+	return func(S *Search) { S.params.Gens = gens }
+}
+
+func WithIslands(islands, popSize int) Option {
+	return func(S *Search) {
+		S.params.Islands = islands
+		S.params.PopSize = popSize
+	}
+}
+
+// WithLockstep runs the islands in lockstep generations,
+// which makes seeded runs reproducible
+func WithLockstep() Option {
+	return func(S *Search) { S.params.SyncReports = true }
+}
+
+func WithProfiles(profs ...*IslandProfile) Option {
+	return func(S *Search) { S.params.Profiles = profs }
+}
+
+func WithEvalWorkers(workers int) Option {
+	return func(S *Search) { S.params.EvalWorkers = workers }
+}
+
+// WithMigration sends count equations to each neighbor in
+// topology every epoch generations, 0 disables migration
+func WithMigration(topology string, epoch, count int) Option {
+	return func(S *Search) {
+		S.params.Topology = topology
+		S.params.MigEpoch = epoch
+		S.params.MigCount = count
+	}
+}
+
+func WithFitness(f Fitness) Option {
+	return func(S *Search) { S.params.Fitness = f }
+}
+
+// WithObserver adds an observer, which is called for every event
+func WithObserver(o Observer) Option {
+	return func(S *Search) { S.addObserver(o) }
+}
@@ -0,0 +1,81 @@
+package eureqa
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := DefaultParams().Validate(); err != nil {
+		t.Fatalf("default params: %v", err)
+	}
+	bad := map[string]func(p *Params){
+		"no islands":     func(p *Params) { p.Islands = 0 },
+		"big report":     func(p *Params) { p.RptSize = p.PopSize + 1 },
+		"cross rate":     func(p *Params) { p.CrossRate = 1.5 },
+		"no workers":     func(p *Params) { p.EvalWorkers = 0 },
+		"size limits":    func(p *Params) { p.Tree.MaxSize = p.Tree.MinSize - 1 },
+		"no leafs":       func(p *Params) { p.Tree.LeafsT = nil },
+		"topology":       func(p *Params) { p.Topology = "mesh" },
+		"migrant select": func(p *Params) { p.MigSelect = MIG_SEL_NOVEL + 1 },
+		"profile rate": func(p *Params) {
+			prof := NewProfile("bad", p)
+			prof.MutateRate = -1
+			p.Profiles = []*IslandProfile{prof}
+		},
+	}
+	for name, change := range bad {
+		p := DefaultParams()
+		change(p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestRunRejectsParams(t *testing.T) {
+	srch := NewSearch(quadData(), WithIslands(0, 10))
+	if _, err := srch.Run(context.Background()); err == nil {
+		t.Error("no error for a search without islands")
+	}
+}
+
+func TestRunKeepsParams(t *testing.T) {
+	p := DefaultParams()
+	p.Fitness = nil
+	p.Gens, p.Islands, p.PopSize = 3, 2, 20
+	prof := NewProfile("mine", p)
+	p.Profiles = []*IslandProfile{prof}
+	leafs := len(p.Tree.LeafsT)
+
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.data"), filepath.Join(dir, "b.data")}
+	for i, text := range []string{"# system: k=1\nx y\n1 2\n2 3\n3 4\n", "# system: k=2\nx y\n1 3\n2 4\n3 5\n"} {
+		if err := ioutil.WriteFile(files[i], []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := ReadExperiments(files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := NewSearch(data, WithParams(p), WithLockstep()).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Fitness != nil || p.Seed != 0 || p.SyncReports {
+		t.Errorf("fitness %v, seed %d, lockstep %v changed", p.Fitness, p.Seed, p.SyncReports)
+	}
+	if len(p.Tree.UsableVars) != 0 || len(p.Tree.LeafsT) != leafs || p.Tree.NumSys != 0 {
+		t.Errorf("tree settings changed: vars %v, leafs %v", p.Tree.UsableVars, p.Tree.LeafsT)
+	}
+	if len(prof.Tree.UsableVars) != 0 || len(prof.Tree.LeafsT) != leafs {
+		t.Errorf("profile settings changed: vars %v, leafs %v", prof.Tree.UsableVars, prof.Tree.LeafsT)
+	}
+	if res.Seed == 0 {
+		t.Error("the result doesn't record the seed it picked")
+	}
+}
@@ -0,0 +1,482 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// Primitive is a user defined unary or binary function for equations.
+// Registered primitives are used by adding their ExprType to the
+// TreeParams, directly or with Params.UsePrimitives.
+type Primitive struct {
+	Name   string
+	Arity  int // 1 or 2
+	Unary  func(x float64) float64
+	Binary func(x, y float64) float64
+
+	// how much more complex than a node of its own the primitive
+	// is, counted in the size of equations, 1 when 0
+	Weight int
+
+	// fmt format with a %s for each argument,
+	// Name(%s) or Name(%s, %s) when empty
+	Format string
+	// the same for Latex and Javascript output,
+	// \mathrm{Name}\left(%s\right) and Format when empty
+	Latex, Javascript string
+
+	// generated like sin and cos, never inside another trig function
+	Trig bool
+}
+
+// USER_PRIM is the ExprType of the first registered primitive,
+// well clear of the go-symexpr types
+const USER_PRIM ExprType = 1000
+
+var primitives []*Primitive
+
+// registered primitives with a Weight above 1
+var weighted bool
+
+// RegisterPrimitive makes p available to equations and returns its
+// ExprType. Primitives must be registered before searches start.
+func RegisterPrimitive(p Primitive) ExprType {
+	if p.Arity == 1 && p.Unary == nil || p.Arity == 2 && p.Binary == nil || p.Arity < 1 || p.Arity > 2 {
+		panic("eureqa: primitive " + p.Name + " needs a function of its arity, 1 or 2")
+	}
+	if _, dup := PrimitiveByName(p.Name); dup {
+		panic("eureqa: primitive " + p.Name + " registered twice")
+	}
+	if p.Weight < 1 {
+		p.Weight = 1
+	}
+	if p.Format == "" {
+		p.Format = p.Name + "(%s" + strings.Repeat(", %s", p.Arity-1) + ")"
+	}
+	if p.Latex == "" {
+		p.Latex = `\mathrm{` + p.Name + `}\left(%s` + strings.Repeat(", %s", p.Arity-1) + `\right)`
+	}
+	if p.Javascript == "" {
+		p.Javascript = p.Format
+	}
+	weighted = weighted || p.Weight > 1
+
+	primitives = append(primitives, &p)
+	return USER_PRIM + ExprType(len(primitives)-1)
+}
+
+// PrimitiveByName returns the ExprType of a registered primitive
+func PrimitiveByName(name string) (ExprType, bool) {
+	for i, p := range primitives {
+		if p.Name == name {
+			return USER_PRIM + ExprType(i), true
+		}
+	}
+	return NULL, false
+}
+
+func primitive(t ExprType) *Primitive {
+	return primitives[t-USER_PRIM]
+}
+
+// the primitives which come with the package, ParseFormula
+// uses POW for powers which aren't small integers
+var (
+	TANH = RegisterPrimitive(Primitive{Name: "tanh", Arity: 1, Unary: math.Tanh,
+		Latex: `\tanh\left(%s\right)`, Javascript: "Math.tanh(%s)"})
+	SIGMOID = RegisterPrimitive(Primitive{Name: "sigmoid", Arity: 1, Unary: sigmoid,
+		Latex: `\sigma\left(%s\right)`, Javascript: "(1/(1+Math.exp(-(%s))))"})
+	ERF = RegisterPrimitive(Primitive{Name: "erf", Arity: 1, Unary: math.Erf,
+		Latex: `\mathrm{erf}\left(%s\right)`})
+	POW = RegisterPrimitive(Primitive{Name: "pow", Arity: 2, Binary: math.Pow, Format: "(%s)^(%s)",
+		Latex: `{\left(%s\right)}^{%s}`, Javascript: "Math.pow(%s, %s)"})
+)
+
+// sigmoid saturates at 0 and 1 without overflowing
+func sigmoid(x float64) float64 {
+	if x < 0 {
+		ex := math.Exp(x)
+		return ex / (1 + ex)
+	}
+	return 1 / (1 + math.Exp(-x))
+}
+
+// UsePrimitives adds the named primitives to the functions equations are
+// made of, those which aren't Trig also to the ones used inside trig
+func (p *Params) UsePrimitives(names ...string) error {
+	for _, name := range names {
+		t, ok := PrimitiveByName(name)
+		if !ok {
+			return fmt.Errorf("unknown primitive %q", name)
+		}
+		p.Tree.NodesT = append(p.Tree.NodesT, t)
+		if !primitive(t).Trig {
+			p.Tree.NonTrigT = append(p.Tree.NonTrigT, t)
+		}
+	}
+	return nil
+}
+
+// complexity is the size of an equation counting the weights
+// of its primitives, just the size when none are weighted
+func complexity(e Expr) int {
+	size := e.Size()
+	if !weighted {
+		return size
+	}
+	cplx := size
+	for i := 0; i < size; i++ {
+		pos := i
+		if u, ok := e.GetExpr(&pos).(*UserFunc); ok {
+			cplx += primitive(u.T).Weight - 1
+		}
+	}
+	return cplx
+}
+
+// UserFunc is an equation node applying a registered primitive,
+// it takes part in go-symexpr's tree walks through the Expr methods
+// and has the rest of the node methods for its callers
+type UserFunc struct {
+	T    ExprType
+	Args []Expr
+
+	size, depth, height int
+}
+
+var _ Expr = (*UserFunc)(nil)
+
+func NewUserFunc(t ExprType, args ...Expr) *UserFunc {
+	return &UserFunc{T: t, Args: args}
+}
+
+func (u *UserFunc) ExprType() ExprType { return u.T }
+func (u *UserFunc) NumChildren() int   { return len(u.Args) }
+func (u *UserFunc) Size() int          { return u.size }
+func (u *UserFunc) Depth() int         { return u.depth }
+func (u *UserFunc) Height() int        { return u.height }
+
+func (u *UserFunc) Clone() Expr {
+	c := &UserFunc{T: u.T, Args: make([]Expr, len(u.Args)), size: u.size, depth: u.depth, height: u.height}
+	for i, a := range u.Args {
+		c.Args[i] = a.Clone()
+	}
+	return c
+}
+
+// CalcExprStats counts depth from u, as go-symexpr's nodes
+// do from the one it is called on, see depthAt
+func (u *UserFunc) CalcExprStats() {
+	u.calcStats(0)
+}
+
+func (u *UserFunc) calcStats(depth int) {
+	u.size, u.depth, u.height = 1, depth, 1
+	for _, a := range u.Args {
+		if au, ok := a.(*UserFunc); ok {
+			au.calcStats(depth + 1)
+		} else {
+			a.CalcExprStats()
+		}
+		u.size += a.Size()
+		if a.Height()+1 > u.height {
+			u.height = a.Height() + 1
+		}
+	}
+}
+
+func (u *UserFunc) Eval(t float64, x, c, s []float64) float64 {
+	p := primitive(u.T)
+	if p.Arity == 1 {
+		return p.Unary(u.Args[0].Eval(t, x, c, s))
+	}
+	return p.Binary(u.Args[0].Eval(t, x, c, s), u.Args[1].Eval(t, x, c, s))
+}
+
+// Simplify simplifies the arguments, and folds
+// the primitive away when they are all constants
+func (u *UserFunc) Simplify(rules SimpRules) Expr {
+	consts := true
+	for i, a := range u.Args {
+		if u.Args[i] = a.Simplify(rules); u.Args[i] == nil {
+			return nil
+		}
+		_, isConst := u.Args[i].(*ConstantF)
+		consts = consts && isConst
+	}
+	if consts {
+		return NewConstantF(u.Eval(0, nil, nil, nil))
+	}
+	return u
+}
+
+func (u *UserFunc) String() string {
+	args := make([]interface{}, len(u.Args))
+	for i, a := range u.Args {
+		args[i] = a.String()
+	}
+	return fmt.Sprintf(primitive(u.T).Format, args...)
+}
+
+func (u *UserFunc) HasVar() bool {
+	for _, a := range u.Args {
+		if a.HasVar() {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UserFunc) Serial(s []int) []int {
+	s = append(s, int(u.T))
+	for _, a := range u.Args {
+		s = a.Serial(s)
+	}
+	return s
+}
+
+// AmILess orders by primitive and then by the arguments in turn
+func (u *UserFunc) AmILess(r Expr) bool {
+	if u.T != r.ExprType() {
+		return u.T < r.ExprType()
+	}
+	ru := r.(*UserFunc)
+	for i, a := range u.Args {
+		if a.AmILess(ru.Args[i]) {
+			return true
+		}
+		if ru.Args[i].AmILess(a) {
+			return false
+		}
+	}
+	return false
+}
+
+func (u *UserFunc) AmIEqual(r Expr) bool {
+	return u.sameArgs(r, func(a, b Expr) bool { return a.AmIEqual(b) })
+}
+func (u *UserFunc) AmISame(r Expr) bool {
+	return u.sameArgs(r, func(a, b Expr) bool { return a.AmISame(b) })
+}
+func (u *UserFunc) AmIAlmostSame(r Expr) bool {
+	return u.sameArgs(r, func(a, b Expr) bool { return a.AmIAlmostSame(b) })
+}
+
+// sameArgs is whether r applies the same primitive
+// to arguments which are the same by cmp
+func (u *UserFunc) sameArgs(r Expr, cmp func(a, b Expr) bool) bool {
+	ru, ok := r.(*UserFunc)
+	if !ok || ru.T != u.T || len(ru.Args) != len(u.Args) {
+		return false
+	}
+	for i, a := range u.Args {
+		if !cmp(a, ru.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetExpr and SetExpr count pos down through the
+// nodes in preorder, as go-symexpr's own nodes do
+func (u *UserFunc) GetExpr(pos *int) Expr {
+	if *pos == 0 {
+		return u
+	}
+	for _, a := range u.Args {
+		*pos--
+		if e := a.GetExpr(pos); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (u *UserFunc) SetExpr(pos *int, e Expr) (replace_me, replaced bool) {
+	if *pos == 0 {
+		return true, false
+	}
+	for i, a := range u.Args {
+		*pos--
+		rm, rd := a.SetExpr(pos, e)
+		if rm {
+			u.Args[i] = e
+			return false, true
+		}
+		if rd {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Sort sorts within the arguments, which keep their order
+func (u *UserFunc) Sort() {
+	for _, a := range u.Args {
+		if s, ok := a.(interface{ Sort() }); ok {
+			s.Sort()
+		}
+	}
+}
+
+// nodes calls f on u and every node below it, in preorder
+func (u *UserFunc) nodes(f func(e Expr)) {
+	for i := 0; ; i++ {
+		pos := i
+		e := u.GetExpr(&pos)
+		if e == nil {
+			return
+		}
+		f(e)
+	}
+}
+
+func (u *UserFunc) HasVarI(i int) bool   { return u.count(VAR, i) > 0 }
+func (u *UserFunc) NumVar() int          { return u.count(VAR, -1) }
+func (u *UserFunc) HasConst() bool       { return u.count(CONSTANT, -1) > 0 }
+func (u *UserFunc) HasConstI(i int) bool { return u.count(CONSTANT, i) > 0 }
+func (u *UserFunc) NumConstants() int    { return u.count(CONSTANT, -1) }
+
+// count counts the VAR or CONSTANT nodes with index p, any when p is -1
+func (u *UserFunc) count(t ExprType, p int) int {
+	n := 0
+	u.nodes(func(e Expr) {
+		switch e := e.(type) {
+		case *Var:
+			if t == VAR && (p < 0 || e.P == p) {
+				n++
+			}
+		case *Constant:
+			if t == CONSTANT && (p < 0 || e.P == p) {
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// StackSerial is Serial in postorder
+func (u *UserFunc) StackSerial(s []int) []int {
+	for _, a := range u.Args {
+		if ss, ok := a.(interface{ StackSerial([]int) []int }); ok {
+			s = ss.StackSerial(s)
+		} else {
+			s = a.Serial(s)
+		}
+	}
+	return append(s, int(u.T))
+}
+
+// ConvertToConstants turns the ConstantF arguments into Constants
+// indexing cs, to which it appends their values
+func (u *UserFunc) ConvertToConstants(cs []float64) []float64 {
+	for i, a := range u.Args {
+		switch a := a.(type) {
+		case *ConstantF:
+			u.Args[i] = NewConstant(len(cs))
+			cs = append(cs, a.F)
+		case interface{ ConvertToConstants([]float64) []float64 }:
+			cs = a.ConvertToConstants(cs)
+		}
+	}
+	return cs
+}
+
+// IndexConstants numbers the Constants below u
+// in preorder from ci, and returns the next index
+func (u *UserFunc) IndexConstants(ci int) int {
+	for _, a := range u.Args {
+		switch a := a.(type) {
+		case *Constant:
+			a.P = ci
+			ci++
+		case interface{ IndexConstants(int) int }:
+			ci = a.IndexConstants(ci)
+		}
+	}
+	return ci
+}
+
+// ConvertToConstantFs replaces the Constants with their values in cs
+func (u *UserFunc) ConvertToConstantFs(cs []float64) Expr {
+	for i, a := range u.Args {
+		switch a := a.(type) {
+		case *Constant:
+			u.Args[i] = NewConstantF(cs[a.P])
+		case interface{ ConvertToConstantFs([]float64) Expr }:
+			u.Args[i] = a.ConvertToConstantFs(cs)
+		}
+	}
+	return u
+}
+
+// DerivVar and DerivConst are 0 when the arguments don't depend on
+// the variable or constant. Primitives are only numeric functions, so
+// otherwise there is no derivative to give and they are nil.
+func (u *UserFunc) DerivVar(i int) Expr {
+	if u.HasVarI(i) {
+		return nil
+	}
+	return NewConstantF(0)
+}
+func (u *UserFunc) DerivConst(i int) Expr {
+	if u.HasConstI(i) {
+		return nil
+	}
+	return NewConstantF(0)
+}
+
+// printer is how go-symexpr's nodes print with names and constant values
+type printer interface {
+	PrettyPrint(dnames, snames []string, cvals []float64) string
+	Latex(dnames, snames []string, cvals []float64) string
+	Javascript(dnames, snames []string, cvals []float64) string
+}
+
+func (u *UserFunc) PrettyPrint(dnames, snames []string, cvals []float64) string {
+	return u.print(primitive(u.T).Format, func(p printer) string { return p.PrettyPrint(dnames, snames, cvals) })
+}
+func (u *UserFunc) Latex(dnames, snames []string, cvals []float64) string {
+	return u.print(primitive(u.T).Latex, func(p printer) string { return p.Latex(dnames, snames, cvals) })
+}
+func (u *UserFunc) Javascript(dnames, snames []string, cvals []float64) string {
+	return u.print(primitive(u.T).Javascript, func(p printer) string { return p.Javascript(dnames, snames, cvals) })
+}
+
+// print fills format with the arguments printed by f,
+// those which can't print that way use String
+func (u *UserFunc) print(format string, f func(p printer) string) string {
+	args := make([]interface{}, len(u.Args))
+	for i, a := range u.Args {
+		if p, ok := a.(printer); ok {
+			args[i] = f(p)
+		} else {
+			args[i] = a.String()
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// depthAt is the depth of the node at preorder position pos of e,
+// for trees with UserFuncs, which go-symexpr's nodes count from 0
+func depthAt(e Expr, pos int) int {
+	depth := 0
+	for pos > 0 {
+		// find the child holding pos, they start after e
+		off := 1
+		for {
+			p := off
+			c := e.GetExpr(&p)
+			if pos < off+c.Size() {
+				e, pos = c, pos-off
+				break
+			}
+			off += c.Size()
+		}
+		depth++
+	}
+	return depth
+}
@@ -0,0 +1,103 @@
+package eureqa
+
+import (
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// userTree is x0 + tanh(pow(x1, 2)), preorder:
+// 0 add, 1 x0, 2 tanh, 3 pow, 4 x1, 5 2
+func userTree(c float64) Expr {
+	add := NewAdd()
+	add.Insert(NewVar(0))
+	add.Insert(NewUserFunc(TANH, NewUserFunc(POW, NewVar(1), NewConstantF(c))))
+	add.CalcExprStats()
+	return add
+}
+
+func TestUserFuncDepth(t *testing.T) {
+	e := userTree(2)
+	for pos, want := range []int{0, 1, 1, 2, 3, 3} {
+		if got := depthAt(e, pos); got != want {
+			t.Errorf("depth at %d is %d, want %d", pos, got, want)
+		}
+	}
+
+	tanh := NewUserFunc(TANH, NewUserFunc(POW, NewVar(1), NewConstantF(2)))
+	tanh.CalcExprStats()
+	if d := tanh.Args[0].Depth(); d != 1 {
+		t.Errorf("pow under tanh has depth %d, want 1", d)
+	}
+	if h := tanh.Height(); h != 3 {
+		t.Errorf("tanh has height %d, want 3", h)
+	}
+}
+
+func TestUserFuncCompare(t *testing.T) {
+	a, b := userTree(2), userTree(2)
+	ua, ub := a.(*Add).CS[1], b.(*Add).CS[1]
+	if !ua.AmIAlmostSame(ub) || !ua.AmISame(ub) || !ua.AmIEqual(ub) {
+		t.Error("equal trees aren't the same")
+	}
+	if ua.AmILess(ub) || ub.AmILess(ua) {
+		t.Error("equal trees are less")
+	}
+
+	// the arguments decide, in turn
+	other := NewUserFunc(TANH, NewUserFunc(POW, NewVar(0), NewConstantF(2)))
+	if ua.AmIAlmostSame(other) || ua.AmIEqual(other) {
+		t.Error("different arguments are the same")
+	}
+	if other.AmILess(ua) != NewVar(0).AmILess(NewVar(1)) {
+		t.Error("not ordered by the arguments")
+	}
+	if ua.AmIAlmostSame(NewUserFunc(SIGMOID, NewUserFunc(POW, NewVar(1), NewConstantF(2)))) {
+		t.Error("different primitives are the same")
+	}
+}
+
+func TestUserFuncPrint(t *testing.T) {
+	u := userTree(2).(*Add).CS[1].(*UserFunc)
+	pow := u.Args[0].(*UserFunc)
+	want := `\tanh\left({\left(` + pow.Args[0].String() + `\right)}^{` + pow.Args[1].String() + `}\right)`
+	if _, ok := pow.Args[0].(printer); ok {
+		want = `\tanh\left({\left(` + pow.Args[0].(printer).Latex(nil, nil, nil) + `\right)}^{` +
+			pow.Args[1].(printer).Latex(nil, nil, nil) + `}\right)`
+	}
+	if got := u.Latex(nil, nil, nil); got != want {
+		t.Errorf("latex %q, want %q", got, want)
+	}
+	if u.NumVar() != 1 || !u.HasVarI(1) || u.HasVarI(0) || u.HasConst() {
+		t.Errorf("counts of %v", u)
+	}
+}
+
+func TestUserFuncConstants(t *testing.T) {
+	u := NewUserFunc(POW, NewVar(0), NewConstantF(3))
+	cs := u.ConvertToConstants([]float64{1.5})
+	if len(cs) != 2 || cs[1] != 3 {
+		t.Fatalf("constants %v", cs)
+	}
+	if c, ok := u.Args[1].(*Constant); !ok || c.P != 1 {
+		t.Fatalf("argument %v isn't constant 1", u.Args[1])
+	}
+	if !u.HasConstI(1) || u.NumConstants() != 1 {
+		t.Errorf("counts of %v", u)
+	}
+
+	if next := u.IndexConstants(0); next != 1 || u.Args[1].(*Constant).P != 0 {
+		t.Errorf("indexed to %v, next %d", u, next)
+	}
+	u.ConvertToConstantFs([]float64{4})
+	if y := u.Eval(0, []float64{2}, nil, nil); y != 16 {
+		t.Errorf("pow(2, 4) is %g", y)
+	}
+
+	if d := u.DerivVar(1); d == nil || d.Eval(0, []float64{2, 2}, nil, nil) != 0 {
+		t.Errorf("derivative in x1 of %v is %v", u, d)
+	}
+	if d := u.DerivVar(0); d != nil {
+		t.Errorf("derivative in x0 of %v is %v", u, d)
+	}
+}
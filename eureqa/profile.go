@@ -0,0 +1,146 @@
+package eureqa
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// IslandProfile is the tree and operator settings of an island,
+// so islands of one search can explore in different ways
+type IslandProfile struct {
+	Name string
+	Tree *TreeParams
+
+	CrossRate  float64
+	MutateRate float64
+}
+
+// NewProfile copies the search wide settings
+func NewProfile(name string, srp *Params) *IslandProfile {
+	p := new(IslandProfile)
+	p.Name = name
+	p.Tree = srp.Tree.Clone()
+	p.CrossRate = srp.CrossRate
+	p.MutateRate = srp.MutateRate
+	return p
+}
+
+func (p *IslandProfile) Clone() *IslandProfile {
+	c := *p
+	if p.Tree != nil {
+		c.Tree = p.Tree.Clone()
+	}
+	return &c
+}
+
+func (p *IslandProfile) noTrig() {
+	p.Tree.NodesT = make([]ExprType, len(p.Tree.NonTrigT))
+	copy(p.Tree.NodesT, p.Tree.NonTrigT)
+}
+
+// SpreadProfiles generates n profiles which alternate with and without
+// trig functions, between full and compact size limits, and move from
+// exploratory to exploitative operator rates
+func SpreadProfiles(srp *Params, n int) []*IslandProfile {
+	profs := make([]*IslandProfile, n)
+	for i := 0; i < n; i++ {
+		p := NewProfile("", srp)
+
+		funcs := "trig"
+		if i%2 == 1 {
+			funcs = "notrig"
+			p.noTrig()
+		}
+
+		size := "full"
+		if (i/2)%2 == 1 {
+			size = "compact"
+			p.Tree.MaxSize = maxInt(p.Tree.MinSize, p.Tree.MaxSize/2)
+			p.Tree.MaxDepth = maxInt(p.Tree.MinDepth, p.Tree.MaxDepth-1)
+		}
+
+		t := 0.5
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		p.CrossRate = 0.5 + 0.4*t
+		p.MutateRate = 0.4 - 0.3*t
+
+		p.Name = fmt.Sprintf("%s/%s x%.2f m%.2f", funcs, size, p.CrossRate, p.MutateRate)
+		profs[i] = p
+	}
+	return profs
+}
+
+// ReadProfilesFile reads a whitespace separated table of profiles.
+// The header names the columns, any of: name, cross, mutate, maxsize,
+// maxdepth and trig (0 or 1). Missing columns keep the search settings,
+// and the rates must be in [0,1].
+func ReadProfilesFile(filename string, srp *Params) ([]*IslandProfile, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(data, []byte{'\n'})
+	cols := strings.Fields(string(lines[0]))
+
+	var profs []*IslandProfile
+	for l := 1; l < len(lines); l++ {
+		vals := strings.Fields(string(lines[l]))
+		if len(vals) == 0 {
+			continue
+		}
+		if len(vals) != len(cols) {
+			return nil, fmt.Errorf("%s:%d: %d values for %d columns", filename, l+1, len(vals), len(cols))
+		}
+
+		p := NewProfile(fmt.Sprintf("profile%d", len(profs)), srp)
+		for c, col := range cols {
+			var err error
+			switch strings.ToLower(col) {
+			case "name":
+				p.Name = vals[c]
+			case "cross":
+				p.CrossRate, err = strconv.ParseFloat(vals[c], 64)
+			case "mutate":
+				p.MutateRate, err = strconv.ParseFloat(vals[c], 64)
+			case "maxsize":
+				p.Tree.MaxSize, err = strconv.Atoi(vals[c])
+			case "maxdepth":
+				p.Tree.MaxDepth, err = strconv.Atoi(vals[c])
+			case "trig":
+				var trig bool
+				trig, err = strconv.ParseBool(vals[c])
+				if !trig {
+					p.noTrig()
+				}
+			default:
+				return nil, fmt.Errorf("%s:1: unknown profile column %q", filename, col)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: column %s: %v", filename, l+1, col, err)
+			}
+		}
+		if err := checkRates(p.CrossRate, p.MutateRate); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, l+1, err)
+		}
+		profs = append(profs, p)
+	}
+
+	if len(profs) == 0 {
+		return nil, fmt.Errorf("%s: no profiles", filename)
+	}
+	return profs, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,99 @@
+package eureqa
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpreadProfiles(t *testing.T) {
+	srp := DefaultParams()
+	for _, n := range []int{1, 2, 5, 8} {
+		profs := SpreadProfiles(srp, n)
+		if len(profs) != n {
+			t.Fatalf("%d islands: %d profiles", n, len(profs))
+		}
+		for i, p := range profs {
+			if err := checkRates(p.CrossRate, p.MutateRate); err != nil {
+				t.Errorf("%d islands, profile %d: %v", n, i, err)
+			}
+			if err := p.Tree.validate(); err != nil {
+				t.Errorf("%d islands, profile %d: %v", n, i, err)
+			}
+			trig := !strings.HasPrefix(p.Name, "notrig")
+			if trig != (i%2 == 0) {
+				t.Errorf("%d islands, profile %d is %q", n, i, p.Name)
+			}
+			if !trig && len(p.Tree.NodesT) != len(srp.Tree.NonTrigT) {
+				t.Errorf("%d islands, profile %d keeps trig nodes", n, i)
+			}
+			compact := strings.Contains(p.Name, "compact")
+			if compact != ((i/2)%2 == 1) {
+				t.Errorf("%d islands, profile %d is %q", n, i, p.Name)
+			}
+			if compact && p.Tree.MaxSize >= srp.Tree.MaxSize {
+				t.Errorf("%d islands, compact profile %d has max size %d", n, i, p.Tree.MaxSize)
+			}
+		}
+		// exploratory to exploitative
+		if n > 1 && !(profs[0].CrossRate < profs[n-1].CrossRate && profs[0].MutateRate > profs[n-1].MutateRate) {
+			t.Errorf("%d islands: rates don't move from %q to %q", n, profs[0].Name, profs[n-1].Name)
+		}
+		// the search settings are copied, not shared
+		if n > 1 && profs[0].Tree == profs[1].Tree {
+			t.Errorf("%d islands: profiles share tree settings", n)
+		}
+	}
+}
+
+func writeProfiles(t *testing.T, text string) string {
+	file := filepath.Join(t.TempDir(), "profiles")
+	if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReadProfilesFile(t *testing.T) {
+	srp := DefaultParams()
+	file := writeProfiles(t, "name cross mutate maxsize trig\nwide 0.9 0.1 40 1\n\nnarrow 0.5 0.3 20 0\n")
+	profs, err := ReadProfilesFile(file, srp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profs) != 2 {
+		t.Fatalf("%d profiles, want 2", len(profs))
+	}
+	if p := profs[0]; p.Name != "wide" || p.CrossRate != 0.9 || p.MutateRate != 0.1 || p.Tree.MaxSize != 40 {
+		t.Errorf("first profile %+v", p)
+	}
+	if p := profs[1]; p.Name != "narrow" || p.Tree.MaxSize != 20 || len(p.Tree.NodesT) != len(srp.Tree.NonTrigT) {
+		t.Errorf("second profile %+v", p)
+	}
+	// missing columns keep the search settings
+	if profs[0].Tree.MaxDepth != srp.Tree.MaxDepth {
+		t.Errorf("max depth %d, want %d", profs[0].Tree.MaxDepth, srp.Tree.MaxDepth)
+	}
+}
+
+func TestReadProfilesFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"rate above 1":   "cross mutate\n0.5 0.2\n1.5 0.2\n",
+		"negative rate":  "mutate\n-0.1\n",
+		"not a rate":     "cross\nhigh\n",
+		"short row":      "cross mutate\n0.5\n",
+		"unknown column": "crossover\n0.5\n",
+		"no profiles":    "cross mutate\n\n",
+	}
+	for name, text := range tests {
+		if _, err := ReadProfilesFile(writeProfiles(t, text), DefaultParams()); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	_, err := ReadProfilesFile(writeProfiles(t, "cross mutate\n0.5 0.2\n1.5 0.2\n"), DefaultParams())
+	if err == nil || !strings.Contains(err.Error(), ":3:") {
+		t.Errorf("error %v doesn't give line 3", err)
+	}
+}
@@ -0,0 +1,235 @@
+package eureqa
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Result is the outcome of a search
+type Result struct {
+	// the non-dominated equations found, without duplicates, from
+	// smallest and least accurate to largest and most accurate when
+	// the error is the only objective
+	Front []*Model
+
+	Seed      int64 // the master seed, to repeat the search
+	Cancelled bool  // whether the search stopped early
+	Tested    bool  // whether Test measured the front on test data
+
+	// the names of the data's inputs and system
+	// parameters, for printing
+	names, sysNames []string
+	fitted          bool // whether FitExperiments was run
+}
+
+// Model is an equation of the front with its metrics on the search data,
+// weighted by the rows' Weight and leaving out rows skipped for missing
+// values. The embedded Eqn's Err is the mean absolute error.
+type Model struct {
+	*Eqn
+
+	RMSE   float64 // root mean squared error
+	MaxErr float64 // largest absolute error, unweighted
+	R2     float64 // coefficient of determination
+
+	// the error on the test data, like Err on the search data,
+	// set by Result.Test and NaN when the fitness rejects it
+	TestErr float64
+
+	// the equation with coefficients for constants, and their values
+	// fitted to each experiment, set by Result.FitExperiments
+	Shared      *SharedForm
+	Experiments []*ExperimentFit
+}
+
+// NewResult keeps the equations which no other covers, being at least
+// as small and as good in every objective, which also removes duplicates,
+// and measures them on data. Algorithms use it to build their results.
+func NewResult(eqns []*Eqn, data *DataSet) *Result {
+	sorted := make([]*Eqn, 0, len(eqns))
+	for _, e := range eqns {
+		if e != nil && !badEqnFilter(e) {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.Stable(EqnSizeArray(sorted))
+
+	r := &Result{names: data.VarNames(), sysNames: data.SysNames()}
+	for _, e := range sorted {
+		// sorted by size then error, so kept ones can't be covered by e
+		covered := false
+		for _, m := range r.Front {
+			if covers(m.Eqn, e) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			r.Front = append(r.Front, newModel(e, data))
+		}
+	}
+	return r
+}
+
+func newModel(e *Eqn, data *DataSet) *Model {
+	m := &Model{Eqn: e}
+
+	mean, w_sum := 0.0, 0.0
+	for p, y := range data.output {
+		if !data.Skipped(p) {
+			mean += data.Weight(p) * y
+			w_sum += data.Weight(p)
+		}
+	}
+	mean /= w_sum
+
+	ssRes, ssTot := 0.0, 0.0
+	m.EvalEach(data, func(p int, y float64) {
+		w := data.Weight(p)
+		diff := data.output[p] - y
+		ssRes += w * diff * diff
+		ssTot += w * (data.output[p] - mean) * (data.output[p] - mean)
+		m.MaxErr = math.Max(m.MaxErr, math.Abs(diff))
+	})
+	m.RMSE = math.Sqrt(ssRes / w_sum)
+	if ssTot > 0 {
+		m.R2 = 1 - ssRes/ssTot
+	}
+	return m
+}
+
+// PredictOne evaluates the model at one row of inputs, sys are the
+// system values of the experiment, see DataSet.SysVals, nil without
+func (m *Model) PredictOne(x, sys []float64) float64 {
+	return m.Eval(x, sys)
+}
+
+// Predict evaluates the model at each row of inputs,
+// from the experiment with system values sys
+func (m *Model) Predict(inputs [][]float64, sys []float64) []float64 {
+	out := make([]float64, len(inputs))
+	for i, x := range inputs {
+		out[i] = m.PredictOne(x, sys)
+	}
+	return out
+}
+
+// BestByError is the most accurate model, nil if the front is empty
+func (r *Result) BestByError() *Model {
+	return r.BestUnder(math.MaxInt32)
+}
+
+// BestUnder is the most accurate model whose size is at
+// most maxSize, nil if they are all larger
+func (r *Result) BestUnder(maxSize int) *Model {
+	var best *Model
+	for _, m := range r.Front {
+		if m.size <= maxSize && (best == nil || m.err < best.err) {
+			best = m
+		}
+	}
+	return best
+}
+
+// Knee is the model where the size and error trade off bends most, the
+// one farthest below the line from the smallest to the most accurate
+// model, with size and error scaled to [0,1]. Models which are larger
+// and less accurate than another are passed over, as when there are
+// more objectives. It is nil if the front is empty.
+func (r *Result) Knee() *Model {
+	var curve []*Model
+	for _, m := range r.Front {
+		if best := r.BestUnder(m.size); best == m {
+			curve = append(curve, m)
+		}
+	}
+	n := len(curve)
+	if n < 3 {
+		return r.BestByError()
+	}
+	first, last := curve[0], curve[n-1]
+	sizeRange := float64(last.size - first.size)
+	errRange := first.err - last.err
+
+	var knee *Model
+	far := -1.0
+	for _, m := range curve {
+		// the line runs from (0,1) to (1,0) once scaled
+		s := float64(m.size-first.size) / sizeRange
+		e := (m.err - last.err) / errRange
+		if d := 1 - s - e; d > far {
+			far = d
+			knee = m
+		}
+	}
+	return knee
+}
+
+// Test measures the front on test data held out of the search, with
+// the first objective of f, which should be the search's Fitness, so
+// TestErr compares with Err. A nil f is the default MAE.
+func (r *Result) Test(data *DataSet, f Fitness) {
+	if f == nil {
+		f = MAE{}
+	}
+	for _, m := range r.Front {
+		m.TestErr = math.NaN()
+		if objs, ok := f.Objectives(m.Eqn, data); ok && len(objs) > 0 {
+			m.TestErr = objs[0]
+		}
+	}
+	r.Tested = true
+}
+
+// Print writes the seed and then the front, one model per line, with
+// its test error first when the front was tested, and then its shared
+// form and the constants of each experiment when they were fitted, noting
+// the fits which didn't converge. The equations' variables and system
+// parameters are called by their names.
+func (r *Result) Print(w io.Writer) {
+	fmt.Fprintf(w, "seed: %d\n", r.Seed)
+	for i, m := range r.Front {
+		line := r.nameVars(m.String())
+		if r.Tested {
+			fmt.Fprintf(w, "%d: test %.6f  %s", i, m.TestErr, line)
+		} else {
+			fmt.Fprintf(w, "%d: %s", i, line)
+		}
+		if !r.fitted {
+			continue
+		}
+		fmt.Fprintf(w, "    shared: %s\n", r.nameVars(m.Shared.String()))
+		for _, x := range m.Experiments {
+			fmt.Fprintf(w, "    %s: %.6f", x.Name, x.Err)
+			for k, c := range x.Consts {
+				fmt.Fprintf(w, "  C_%d=%.4f", k, c)
+			}
+			if !x.Converged {
+				fmt.Fprint(w, "  (not converged)")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+var varRegexp = regexp.MustCompile(`\b([XS])_(\d+)\b`)
+
+// nameVars replaces the X_0, X_1... and S_0, S_1...
+// of an equation with the names of the columns
+func (r *Result) nameVars(s string) string {
+	return varRegexp.ReplaceAllStringFunc(s, func(v string) string {
+		names := r.names
+		if v[0] == 'S' {
+			names = r.sysNames
+		}
+		p, _ := strconv.Atoi(v[2:])
+		if p < len(names) {
+			return names[p]
+		}
+		return v
+	})
+}
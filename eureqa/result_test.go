@@ -0,0 +1,97 @@
+package eureqa
+
+import (
+	"math"
+	"testing"
+)
+
+// front is a hand built result of models with the given sizes and errors
+func front(sizeErrs ...float64) *Result {
+	r := new(Result)
+	for i := 0; i < len(sizeErrs); i += 2 {
+		r.Front = append(r.Front, &Model{Eqn: testEqn(int(sizeErrs[i]), sizeErrs[i+1])})
+	}
+	return r
+}
+
+func modelSize(m *Model) int {
+	if m == nil {
+		return -1
+	}
+	return m.Size()
+}
+
+func TestResultPicks(t *testing.T) {
+	// size 7 is larger and worse than size 5, as with more objectives
+	r := front(1, 1.0, 3, 0.5, 5, 0.1, 7, 0.3, 9, 0.08, 15, 0.07)
+	tests := []struct {
+		name string
+		got  *Model
+		size int
+	}{
+		{"knee", r.Knee(), 5},
+		{"best", r.BestByError(), 15},
+		{"best under 4", r.BestUnder(4), 3},
+		{"best under 8", r.BestUnder(8), 5},
+		{"best under 1", r.BestUnder(1), 1},
+		{"best under 0", r.BestUnder(0), -1},
+	}
+	for _, tt := range tests {
+		if s := modelSize(tt.got); s != tt.size {
+			t.Errorf("%s: size %d, want %d", tt.name, s, tt.size)
+		}
+	}
+
+	empty := new(Result)
+	if empty.Knee() != nil || empty.BestByError() != nil || empty.BestUnder(10) != nil {
+		t.Error("an empty front picks a model")
+	}
+
+	one := front(4, 0.2)
+	if one.Knee() != one.Front[0] || one.BestByError() != one.Front[0] || one.BestUnder(4) != one.Front[0] {
+		t.Error("a one model front doesn't pick it")
+	}
+	if one.BestUnder(3) != nil {
+		t.Error("best under 3 of a size 4 model")
+	}
+
+	// too few models on the curve for a bend
+	two := front(2, 0.5, 6, 0.1)
+	if two.Knee() != two.Front[1] {
+		t.Errorf("knee of two models is size %d", modelSize(two.Knee()))
+	}
+}
+
+func TestNewResultDedup(t *testing.T) {
+	a := testEqn(1, 1.0)
+	eqns := []*Eqn{
+		testEqn(3, 0.5),
+		a, a, // the same equation twice
+		nil,
+		testEqn(1, 1.0),        // another as good
+		testEqn(4, 0.6),        // covered by the size 3
+		testEqn(2, math.NaN()), // unusable
+		testEqn(5, 0.2),
+	}
+	r := NewResult(eqns, quadData())
+
+	want := []struct {
+		size int
+		err  float64
+	}{{1, 1.0}, {3, 0.5}, {5, 0.2}}
+	if len(r.Front) != len(want) {
+		for _, m := range r.Front {
+			t.Log(m)
+		}
+		t.Fatalf("%d models, want %d", len(r.Front), len(want))
+	}
+	for i, w := range want {
+		if m := r.Front[i]; m.Size() != w.size || m.Err() != w.err {
+			t.Errorf("model %d is size %d error %g, want %d and %g", i, m.Size(), m.Err(), w.size, w.err)
+		}
+	}
+
+	if r := NewResult(nil, quadData()); len(r.Front) != 0 {
+		t.Errorf("%d models from no equations", len(r.Front))
+	}
+}
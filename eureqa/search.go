@@ -0,0 +1,466 @@
+// Package eureqa is an island model symbolic regression engine.
+// The go-eureqa command is a thin wrapper around it:
+//
+//	data, err := eureqa.ReadDataSetFile("data/F1.data", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	srch := eureqa.NewSearch(data, eureqa.WithGens(200), eureqa.WithLockstep())
+//	res, err := srch.Run(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, e := range res.Front {
+//		fmt.Print(e)
+//	}
+package eureqa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	expr "github.com/verdverm/go-symexpr"
+)
+
+type Eqn struct {
+	eqn expr.Expr // embedding the Expr type
+
+	size int
+	err  float64   // the first of objs
+	objs []float64 // from the Fitness, nil until evaluated
+}
+
+// NewEqn wraps an expression and its error
+func NewEqn(e expr.Expr, err float64) *Eqn {
+	e.CalcExprStats()
+	return &Eqn{e, complexity(e), err, nil}
+}
+
+func (e *Eqn) Expr() expr.Expr { return e.eqn }
+func (e *Eqn) Size() int       { return e.size }
+func (e *Eqn) Err() float64    { return e.err }
+
+// Objectives are the values the Fitness gave the equation
+func (e *Eqn) Objectives() []float64 { return e.objs }
+
+func (e *Eqn) objectives() []float64 {
+	if e.objs == nil {
+		return []float64{e.err}
+	}
+	return e.objs
+}
+
+// Eval is the equation's value at one row of inputs, with the system
+// values of its experiment, nil for data without system parameters
+func (e *Eqn) Eval(x, sys []float64) float64 {
+	return e.eqn.Eval(0, x, nil, sys)
+}
+
+func (e *Eqn) String() string {
+	if len(e.objs) > 1 {
+		return fmt.Sprintf("%d  %.6f  %.6f    %v\n", e.size, e.err, e.objs[1:], e.eqn)
+	}
+	return fmt.Sprintf("%d  %.6f    %v\n", e.size, e.err, e.eqn)
+}
+
+func (e *Eqn) Clone() *Eqn {
+	c := e.eqn.Clone()
+	c.CalcExprStats()
+	return &Eqn{c, e.size, e.err, e.objs}
+}
+
+// IslandReport is the best equations of an island at a generation,
+// along with the migrations it made, for the observers
+type IslandReport struct {
+	Id   int
+	Gen  int
+	Eqns []*Eqn
+
+	Sent  []*Migration
+	Recvd int // immigrant equations merged
+}
+
+// ReportChan is the fan-in channel shared by all islands
+type ReportChan chan *IslandReport
+
+// Migrants are cloned equations sent between islands,
+// or from the Search (From -1) to restart an island
+type Migrants struct {
+	From    int
+	Gen     int
+	Eqns    []*Eqn
+	Restart bool
+}
+
+// MigrantChan is an island's inbox for Migrants
+type MigrantChan chan *Migrants
+
+// Migration records a packet of Migrants which left an island
+type Migration struct {
+	From, To, Gen int
+	Count         int
+}
+
+// Params are the settings of a Search
+type Params struct {
+	// search parameters
+	Tree TreeParams
+
+	// scores equations, MAE unless set
+	Fitness Fitness
+
+	// settings file for algorithms which read their own, such as gpsr
+	AlgoConfig string
+
+	// master seed, islands derive theirs from it
+	Seed int64
+
+	Gens    int
+	Islands int
+
+	// lockstep generations over an unbuffered report channel,
+	// which makes seeded runs reproducible when MigInterval is 0
+	SyncReports bool
+
+	// island parameters
+	PopSize int
+	RptSize int
+
+	CrossRate  float64
+	MutateRate float64
+
+	// per island settings, island i uses Profiles[i%len(Profiles)],
+	// when empty every island uses the settings above
+	Profiles []*IslandProfile
+
+	// goroutines evaluating offspring within each island
+	EvalWorkers int
+
+	// restart islands whose front hasn't improved in StagWindow
+	// generations (0 disables), keeping RestartElites of their own
+	// equations and adding RestartImmigrants from the global front
+	StagWindow        int
+	RestartElites     int
+	RestartImmigrants int
+
+	// migration parameters
+	Topology    string
+	MigEpoch    int           // generations between migrations, 0 disables
+	MigInterval time.Duration // wall clock between migrations, overrides MigEpoch
+	MigCount    int           // equations sent to each neighbor
+	MigSelect   MigSelect
+	MigReplace  MigReplace
+}
+
+// Search is an island model genetic programming search
+// for equations which fit a DataSet
+type Search struct {
+	// parameters
+	params *Params
+
+	// internal data
+	data    *DataSet
+	isles   []*Island
+	perEqns [][]*Eqn
+	perGens []int // generations completed by each island
+
+	progress []*islandProgress
+	front    []*Eqn // non-dominated equations of every report so far
+
+	observers []Observer
+
+	// internal comm
+	reports ReportChan
+	migs    []MigrantChan
+	topo    Topology
+}
+
+// NewSearch returns a search of data with DefaultParams
+// changed by the options
+func NewSearch(data *DataSet, opts ...Option) *Search {
+	srch := new(Search)
+	srch.params = DefaultParams()
+	srch.data = data
+	for _, opt := range opts {
+		opt(srch)
+	}
+	return srch
+}
+
+// Params are the search's settings, changes have no effect once
+// the search is running, as Run fills in a copy of them
+func (S *Search) Params() *Params {
+	return S.params
+}
+
+// Run runs the search until params.Gens generations are reached or
+// ctx is cancelled, and returns the front of every report it received.
+// It fails before starting if the params or data are unusable.
+func (S *Search) Run(ctx context.Context) (*Result, error) {
+	S.params = S.params.Clone()
+	if S.params.Fitness == nil {
+		S.params.Fitness = MAE{}
+	}
+	if err := S.params.Validate(); err != nil {
+		return nil, err
+	}
+	if S.data == nil || S.data.Length() == 0 || S.data.Dimensions() == 0 {
+		return nil, fmt.Errorf("no data to search")
+	}
+	if S.params.Seed == 0 {
+		S.params.Seed = time.Now().UnixNano()
+	}
+	S.initSearch()
+	S.runSearch(ctx)
+
+	// the final reports drained by stopIslands never reach S.front
+	r := NewResult(append(S.globalFront(), S.front...), S.data)
+	r.Seed = S.params.Seed
+	r.Cancelled = ctx.Err() != nil
+	return r, nil
+}
+
+func (S *Search) initSearch() {
+	for _, o := range S.observers {
+		o.OnSearchInit(S.params.Islands)
+	}
+
+	// set usable vars now that we have data
+	S.params.Tree.UsableVars = make([]int, S.data.Dimensions())
+	for d := 0; d < S.data.Dimensions(); d++ {
+		S.params.Tree.UsableVars[d] = d
+	}
+
+	// experiments' system parameters are leaves too
+	S.params.Tree.NumSys = len(S.data.SysNames())
+	if S.params.Tree.NumSys > 0 {
+		S.params.Tree.LeafsT = withSystem(S.params.Tree.LeafsT)
+	}
+
+	// islands without a profile share the search settings
+	profs := S.params.Profiles
+	if len(profs) == 0 {
+		profs = []*IslandProfile{NewProfile("default", S.params)}
+	}
+	for _, p := range profs {
+		if len(p.Tree.UsableVars) == 0 {
+			p.Tree.UsableVars = make([]int, len(S.params.Tree.UsableVars))
+			copy(p.Tree.UsableVars, S.params.Tree.UsableVars)
+		}
+		if p.Tree.NumSys = S.params.Tree.NumSys; p.Tree.NumSys > 0 {
+			p.Tree.LeafsT = withSystem(p.Tree.LeafsT)
+		}
+	}
+
+	// initialize the islands
+	S.isles = make([]*Island, S.params.Islands)
+	S.perEqns = make([][]*Eqn, S.params.Islands)
+	S.perGens = make([]int, S.params.Islands)
+	S.progress = make([]*islandProgress, S.params.Islands)
+	for i := range S.progress {
+		S.progress[i] = newIslandProgress()
+	}
+	if S.params.SyncReports {
+		S.reports = make(ReportChan)
+	} else {
+		S.reports = make(ReportChan, 2*S.params.Islands)
+	}
+
+	// every island has an inbox, the topology decides who sends to it
+	// Validate has checked the name
+	S.topo, _ = newTopology(S.params.Topology, S.params.Islands, islandSeed(S.params.Seed, -1))
+	S.migs = make([]MigrantChan, S.params.Islands)
+	for i := 0; i < S.params.Islands; i++ {
+		S.migs[i] = make(MigrantChan, 2*S.params.Islands)
+	}
+
+	for i := 0; i < S.params.Islands; i++ {
+		S.isles[i] = newIsland(i, S.params, profs[i%len(profs)], S.data, S.reports, S.migs, S.topo)
+		S.isles[i].initIsland()
+		for _, o := range S.observers {
+			o.OnIslandStart(i, S.isles[i].prof.Name)
+		}
+	}
+}
+
+// runSearch runs until params.Gens generations are reached or ctx is
+// cancelled. Either way the islands are stopped and perEqns holds
+// their latest reports when it returns.
+func (S *Search) runSearch(ctx context.Context) {
+	for _, o := range S.observers {
+		o.OnSearchStart()
+	}
+
+	for i := 0; i < S.params.Islands; i++ {
+		go S.isles[i].run()
+	}
+
+	if S.params.SyncReports {
+		S.runSync(ctx)
+	} else {
+		S.runAsync(ctx)
+	}
+
+	S.stopIslands()
+	for i := 0; i < S.params.Islands; i++ {
+		for _, o := range S.observers {
+			o.OnIslandStop(i, S.isles[i].eqns)
+		}
+	}
+
+	front := S.globalFront()
+	for _, o := range S.observers {
+		o.OnSearchDone(front, ctx.Err() != nil)
+	}
+}
+
+// runSync steps every island once per generation
+// and waits for all of their reports before continuing
+func (S *Search) runSync(ctx context.Context) {
+	for g := 0; g < S.params.Gens; g++ {
+		if ctx.Err() != nil {
+			return
+		}
+		for i := 0; i < S.params.Islands; i++ {
+			S.isles[i].cmds <- ISLE_STEP
+		}
+		// stopIslands collects any reports left behind on cancel
+		rpts := make([]*IslandReport, S.params.Islands)
+		for i := 0; i < S.params.Islands; i++ {
+			select {
+			case rpt := <-S.reports:
+				S.recvReport(rpt)
+				rpts[rpt.Id] = rpt
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// in island order once all reports are in, for reproducibility
+		for _, rpt := range rpts {
+			S.observeReport(rpt)
+		}
+	}
+}
+
+// runAsync lets the islands run freely and consumes
+// reports as they arrive until every island is done
+func (S *Search) runAsync(ctx context.Context) {
+	for i := 0; i < S.params.Islands; i++ {
+		S.isles[i].cmds <- ISLE_RUN
+	}
+
+	g := 0
+	for g < S.params.Gens {
+		select {
+		case rpt := <-S.reports:
+			S.recvReport(rpt)
+			S.observeReport(rpt)
+		case <-ctx.Done():
+			return
+		}
+
+		// the search generation is that of the slowest island
+		min := S.perGens[0]
+		for _, pg := range S.perGens {
+			if pg < min {
+				min = pg
+			}
+		}
+		if min > g {
+			g = min
+		}
+	}
+}
+
+// stopIslands sends ISLE_QUIT to every island and
+// returns once each one has echoed it back
+func (S *Search) stopIslands() {
+	done := make(chan int, S.params.Islands)
+	for i := 0; i < S.params.Islands; i++ {
+		go func(I *Island) {
+			I.cmds <- ISLE_QUIT
+			<-I.cmds
+			done <- I.Id
+		}(S.isles[i])
+	}
+
+	// keep draining reports so no island blocks on the way out
+	for cnt := 0; cnt < S.params.Islands; {
+		select {
+		case <-done:
+			cnt++
+		case rpt := <-S.reports:
+			S.recvReport(rpt)
+		}
+	}
+}
+
+func (S *Search) recvReport(rpt *IslandReport) {
+	S.perEqns[rpt.Id] = rpt.Eqns
+	if rpt.Gen >= S.perGens[rpt.Id] {
+		S.perGens[rpt.Id] = rpt.Gen + 1
+	}
+}
+
+// addToFront adds e to S.front unless an equation there
+// covers it, returning whether it was added
+func (S *Search) addToFront(e *Eqn) bool {
+	for _, f := range S.front {
+		if covers(f, e) {
+			return false
+		}
+	}
+
+	keep := S.front[:0]
+	for _, f := range S.front {
+		if !covers(e, f) {
+			keep = append(keep, f)
+		}
+	}
+	S.front = append(keep, e)
+	return true
+}
+
+// globalFront merges the latest island reports
+// and pareto sorts them, it may contain nils
+func (S *Search) globalFront() []*Eqn {
+	temp := make([]*Eqn, 0)
+	for i := 0; i < S.params.Islands; i++ {
+		temp = append(temp, S.perEqns[i][:]...)
+	}
+
+	pareto := NewQueueFromArray(temp)
+	pareto.ParetoSort()
+	return temp
+}
+
+/*
+
+Unit Testing
+TEST DRIVEN DEVELOPMENT (TDD)
+  - [BDD] Behavior Driven Development 
+  -
+
+
+-----------------------
+
+Books:
+	Apprenticeship Patterns - Oreilly Publishing
+	The Talent Code - Daniel Coyle
+	Mind, Brain, & Education - 
+	The Lean Startup - Eric Ries
+	Code Specification by Example (BDD?) - 
+
+Trends:
+	functional programming
+	NoSQL data store
+	Single page apps
+	Mobile strategies
+	Cloud hosting
+		AWS
+		GAE
+		Heroku
+	Big Data
+*/
@@ -0,0 +1,46 @@
+package eureqa
+
+import (
+	"context"
+	"testing"
+)
+
+// quadData is y = x0*x0 + x1 on a small grid
+func quadData() *DataSet {
+	var input [][]float64
+	var output []float64
+	for i := -5; i <= 5; i++ {
+		for j := -2; j <= 2; j++ {
+			x0, x1 := float64(i)/2, float64(j)
+			input = append(input, []float64{x0, x1})
+			output = append(output, x0*x0+x1)
+		}
+	}
+	return NewDataSet(input, output, []string{"x", "z"}, "y")
+}
+
+func TestLockstepSeedReproducible(t *testing.T) {
+	run := func() *Result {
+		srch := NewSearch(quadData(), WithSeed(8), WithGens(12), WithIslands(3, 30), WithLockstep(),
+			WithMigration("ring", 3, 2))
+		res, err := srch.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	a, b := run(), run()
+
+	if len(a.Front) == 0 {
+		t.Fatal("empty front")
+	}
+	if len(a.Front) != len(b.Front) {
+		t.Fatalf("fronts of %d and %d models", len(a.Front), len(b.Front))
+	}
+	for i := range a.Front {
+		ma, mb := a.Front[i], b.Front[i]
+		if ma.String() != mb.String() || ma.Err() != mb.Err() || ma.Size() != mb.Size() {
+			t.Errorf("model %d differs:\n%v%v", i, ma, mb)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Searches select equations on a training DataSet, and the front can
+// then be measured on held out test rows with Result.Test to spot
+// equations which fit the training rows too closely.
+
+// The splits are errors when they would leave the training or the
+// test rows empty, and when missing values were filled with the mean
+// or median of the whole column, which the test rows are part of.
+
+// SplitRandom holds out a random frac of the rows for testing
+func (d *DataSet) SplitRandom(frac float64, rng *rand.Rand) (train, test *DataSet, err error) {
+	if err := d.checkImputed(); err != nil {
+		return nil, nil, err
+	}
+	n, err := d.holdCount(frac)
+	if err != nil {
+		return nil, nil, err
+	}
+	held := make([]bool, d.Length())
+	for _, p := range rng.Perm(d.Length())[:n] {
+		held[p] = true
+	}
+	train, test = d.split(held)
+	return train, test, nil
+}
+
+// SplitBlock holds out the last frac of the rows, a contiguous block,
+// for testing, as when later measurements should be predicted
+func (d *DataSet) SplitBlock(frac float64) (train, test *DataSet, err error) {
+	if err := d.checkImputed(); err != nil {
+		return nil, nil, err
+	}
+	n, err := d.holdCount(frac)
+	if err != nil {
+		return nil, nil, err
+	}
+	held := make([]bool, d.Length())
+	for p := d.Length() - n; p < d.Length(); p++ {
+		held[p] = true
+	}
+	train, test = d.split(held)
+	return train, test, nil
+}
+
+// SplitEvery holds out every k-th row for testing
+func (d *DataSet) SplitEvery(k int) (train, test *DataSet, err error) {
+	if err := d.checkImputed(); err != nil {
+		return nil, nil, err
+	}
+	if k < 2 {
+		return nil, nil, fmt.Errorf("holding out every %d-th row leaves no training rows, k must be at least 2", k)
+	}
+	if k > d.Length() {
+		return nil, nil, fmt.Errorf("holding out every %d-th of %d rows leaves no test rows", k, d.Length())
+	}
+	held := make([]bool, d.Length())
+	for p := k - 1; p < d.Length(); p += k {
+		held[p] = true
+	}
+	train, test = d.split(held)
+	return train, test, nil
+}
+
+// SameColumns is an error unless test has the columns of d,
+// as a separately read test file must
+func (d *DataSet) SameColumns(test *DataSet) error {
+	if len(test.var_names) != len(d.var_names) || test.out_name != d.out_name {
+		return fmt.Errorf("test data has columns %v %s, not %v %s", test.var_names, test.out_name, d.var_names, d.out_name)
+	}
+	for i, name := range d.var_names {
+		if test.var_names[i] != name {
+			return fmt.Errorf("test data has columns %v %s, not %v %s", test.var_names, test.out_name, d.var_names, d.out_name)
+		}
+	}
+	return nil
+}
+
+// checkImputed is an error when FillMissing imputed from every row,
+// as the test rows would have leaked into the training rows' values
+func (d *DataSet) checkImputed() error {
+	if d.imputed {
+		return fmt.Errorf("missing values were filled with means or medians of every row, test rows included, so they can't be held out")
+	}
+	return nil
+}
+
+// holdCount is frac of the rows, an error unless
+// it leaves at least one on each side
+func (d *DataSet) holdCount(frac float64) (int, error) {
+	if !(frac > 0 && frac < 1) {
+		return 0, fmt.Errorf("holding out %g of the rows, it must be between 0 and 1", frac)
+	}
+	n := int(math.Round(frac * float64(d.Length())))
+	if n < 1 || n > d.Length()-1 {
+		return 0, fmt.Errorf("holding out %g of %d rows leaves no training or no test rows", frac, d.Length())
+	}
+	return n, nil
+}
+
+// emptyLike is a DataSet without rows, but the columns of d
+func (d *DataSet) emptyLike() *DataSet {
+	return &DataSet{
+		var_names: d.var_names, out_name: d.out_name,
+		weight_name: d.weight_name, time_name: d.time_name,
+		exp_names: d.exp_names, sys_names: d.sys_names, sys: d.sys,
+		missing: d.missing, imputed: d.imputed,
+	}
+}
+
+// split puts the held rows in test and the others in train
+func (d *DataSet) split(held []bool) (train, test *DataSet) {
+	train, test = d.emptyLike(), d.emptyLike()
+	train.cols, test.cols = make([][]float64, len(d.cols)), make([][]float64, len(d.cols))
+	for p := range d.output {
+		to := train
+		if held[p] {
+			to = test
+		}
+		for c, col := range d.cols {
+			to.cols[c] = append(to.cols[c], col[p])
+		}
+		to.output = append(to.output, d.output[p])
+		if d.weights != nil {
+			to.weights = append(to.weights, d.weights[p])
+		}
+		if d.times != nil {
+			to.times = append(to.times, d.times[p])
+		}
+		if d.exp != nil {
+			to.exp = append(to.exp, d.exp[p])
+		}
+		if d.skip != nil {
+			to.skip = append(to.skip, d.skip[p])
+		}
+	}
+	return train, test
+}
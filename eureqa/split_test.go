@@ -0,0 +1,70 @@
+package eureqa
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSplits(t *testing.T) {
+	d := quadData() // 55 rows
+	check := func(name string, train, test *DataSet, err error, ntest int) {
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if train.Length() != d.Length()-ntest || test.Length() != ntest {
+			t.Errorf("%s: %d training and %d test rows, want %d test", name, train.Length(), test.Length(), ntest)
+		}
+	}
+	train, test, err := d.SplitEvery(5)
+	check("every 5", train, test, err, 11)
+	train, test, err = d.SplitBlock(0.2)
+	check("block", train, test, err, 11)
+	if test.Output(0) != d.Output(44) {
+		t.Error("block isn't the last rows")
+	}
+	train, test, err = d.SplitRandom(0.5, rand.New(rand.NewSource(1)))
+	check("random", train, test, err, 28)
+
+	for _, k := range []int{-1, 0, 1, 56} {
+		if _, _, err := d.SplitEvery(k); err == nil {
+			t.Errorf("no error splitting every %d-th row", k)
+		}
+	}
+	for _, frac := range []float64{-0.5, 0, 0.001, 0.999, 1, 2} {
+		if _, _, err := d.SplitBlock(frac); err == nil {
+			t.Errorf("no error holding out %g", frac)
+		}
+		if _, _, err := d.SplitRandom(frac, rand.New(rand.NewSource(1))); err == nil {
+			t.Errorf("no error holding out %g at random", frac)
+		}
+	}
+}
+
+func TestSplitImputed(t *testing.T) {
+	const text = "x y\n1 1\nNA 2\n3 3\n4 4\n5 5\n"
+	for _, ms := range []MissingStrategy{MISS_MEAN, MISS_MEDIAN} {
+		d, err := readString(t, text, &ReadOptions{Missing: &MissingPolicy{Default: ms}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := d.SplitEvery(2); err == nil {
+			t.Errorf("strategy %d: no error splitting imputed rows", ms)
+		}
+		if _, _, err := d.SplitBlock(0.4); err == nil {
+			t.Errorf("strategy %d: no error splitting imputed rows", ms)
+		}
+		if _, _, err := d.SplitRandom(0.4, rand.New(rand.NewSource(1))); err == nil {
+			t.Errorf("strategy %d: no error splitting imputed rows", ms)
+		}
+	}
+
+	// nothing was imputed, or not from other rows
+	for _, text := range []string{"x y\n1 1\n2 2\n3 3\n", text} {
+		d, err := readString(t, text, &ReadOptions{Missing: &MissingPolicy{Default: MISS_MEAN, Columns: map[string]MissingStrategy{"x": MISS_INTERP}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := d.SplitEvery(2); err != nil {
+			t.Errorf("%q: %v", text, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package eureqa
+
+import (
+	"math"
+	"regexp"
+	"sort"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+// islandProgress follows how the fronts an island reports improve
+type islandProgress struct {
+	bestErr  float64
+	hypervol float64
+	unique   map[string]bool // equation structures seen in the reports
+
+	// hypervolume reference point, set by the first report
+	refSize, refErr float64
+
+	lastGain int // generation of the last improvement
+}
+
+func newIslandProgress() *islandProgress {
+	ip := new(islandProgress)
+	ip.unique = make(map[string]bool)
+	ip.reset(0)
+	return ip
+}
+
+// reset forgets the front, but not the equations seen
+// or the reference point, as when the island restarts
+func (ip *islandProgress) reset(gen int) {
+	ip.bestErr = math.Inf(1)
+	ip.hypervol = 0.0
+	ip.lastGain = gen
+}
+
+// update takes in the equations an island reported at gen and
+// returns true if the best error or hypervolume improved
+func (ip *islandProgress) update(eqns []*Eqn, gen, maxSize int) bool {
+	front := make([]*Eqn, 0, len(eqns))
+	for _, e := range eqns {
+		if e != nil && !math.IsInf(e.err, 0) && !math.IsNaN(e.err) {
+			front = append(front, e)
+		}
+	}
+	if len(front) == 0 {
+		return false
+	}
+
+	if ip.refErr == 0.0 {
+		ip.refSize = float64(maxSize + 1)
+		for _, e := range front {
+			ip.refSize = math.Max(ip.refSize, float64(e.size+1))
+			ip.refErr = math.Max(ip.refErr, e.err)
+		}
+		if ip.refErr == 0.0 {
+			ip.refErr = 1.0
+		}
+	}
+
+	best := math.Inf(1)
+	for _, e := range front {
+		best = math.Min(best, e.err)
+		ip.unique[structure(e.eqn)] = true
+	}
+	hv := ip.frontHypervolume(front)
+
+	gain := false
+	if best < ip.bestErr*(1-1e-9) {
+		ip.bestErr = best
+		gain = true
+	}
+	if hv > ip.hypervol*(1+1e-9) {
+		ip.hypervol = hv
+		gain = true
+	}
+	if gain {
+		ip.lastGain = gen
+	}
+	return gain
+}
+
+// constRegexp matches the constants in an equation's String, numbers
+// and indexed constants, but not the digits of names such as X_1
+var constRegexp = regexp.MustCompile(`\bC_\d+\b|-?\b\d+(\.\d+)?([eE][+-]?\d+)?`)
+
+// structure is the equation's String with its constants masked, so
+// equations differing only in their coefficients are the same
+func structure(e Expr) string {
+	return constRegexp.ReplaceAllString(e.String(), "c")
+}
+
+// frontHypervolume is the area of (size, error) space dominated
+// by the equations and bounded by the reference point
+func (ip *islandProgress) frontHypervolume(eqns []*Eqn) float64 {
+	pts := make([]*Eqn, len(eqns))
+	copy(pts, eqns)
+	sort.Stable(EqnSizeArray(pts))
+
+	hv := 0.0
+	prevErr := ip.refErr
+	for _, e := range pts {
+		size := float64(e.size)
+		if size >= ip.refSize || e.err >= prevErr {
+			continue // outside the box or dominated
+		}
+		hv += (ip.refSize - size) * (prevErr - e.err)
+		prevErr = e.err
+	}
+	return hv
+}
+
+// sorts equations by size then error
+type EqnSizeArray []*Eqn
+
+func (ea EqnSizeArray) Len() int { return len(ea) }
+func (ea EqnSizeArray) Less(i, j int) bool {
+	if ea[i].size != ea[j].size {
+		return ea[i].size < ea[j].size
+	}
+	return ea[i].err < ea[j].err
+}
+func (ea EqnSizeArray) Swap(i, j int) {
+	ea[i], ea[j] = ea[j], ea[i]
+}
+
+// checkStagnation restarts island id if it has not improved
+// in the last params.StagWindow generations
+func (S *Search) checkStagnation(id, gen int, stats *GenStats) {
+	ip := S.progress[id]
+	if S.params.StagWindow <= 0 || gen-ip.lastGain < S.params.StagWindow {
+		return
+	}
+	if !S.restartIsland(id, gen) {
+		return
+	}
+	for _, o := range S.observers {
+		o.OnIslandRestart(id, gen, stats)
+	}
+	ip.reset(gen)
+}
+
+// restartIsland has an island reseed itself, keeping RestartElites of its
+// own equations and taking RestartImmigrants from the global front. The
+// request travels through the island's inbox so the Search never blocks.
+func (S *Search) restartIsland(id, gen int) bool {
+	mig := &Migrants{-1, gen, nil, true}
+	for _, e := range S.globalFront() {
+		if len(mig.Eqns) == S.params.RestartImmigrants {
+			break
+		}
+		if e != nil {
+			mig.Eqns = append(mig.Eqns, e.Clone())
+		}
+	}
+
+	select {
+	case S.migs[id] <- mig:
+		return true
+	default:
+		return false // inbox full, try again with the next report
+	}
+}
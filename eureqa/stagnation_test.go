@@ -0,0 +1,22 @@
+package eureqa
+
+import (
+	"testing"
+
+	. "github.com/verdverm/go-symexpr"
+)
+
+func TestStructureMasksConstants(t *testing.T) {
+	eqn := func(c float64, v int) Expr {
+		add := NewAdd()
+		add.Insert(NewVar(v))
+		add.Insert(NewConstantF(c))
+		return add
+	}
+	if a, b := structure(eqn(1.5, 1)), structure(eqn(-22.25, 1)); a != b {
+		t.Errorf("differing constants: %q and %q", a, b)
+	}
+	if a, b := structure(eqn(1.5, 1)), structure(eqn(1.5, 10)); a == b {
+		t.Errorf("differing variables are both %q", a)
+	}
+}
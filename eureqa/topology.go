@@ -0,0 +1,167 @@
+package eureqa
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Topology decides which islands receive an island's migrants
+type Topology interface {
+	// Neighbors returns the islands that island id sends to during epoch
+	Neighbors(id, epoch int) []int
+}
+
+// newTopology returns the named topology over n islands,
+// seed is used by those which are drawn at random
+func newTopology(name string, n int, seed int64) (Topology, error) {
+	switch name {
+	case "ring":
+		return &ringTopology{n}, nil
+	case "biring":
+		return &biringTopology{n}, nil
+	case "star":
+		return &starTopology{n}, nil
+	case "torus":
+		return newTorusTopology(n), nil
+	case "full":
+		return &fullTopology{n}, nil
+	case "random":
+		return &randomTopology{n, 2, seed}, nil
+	}
+	return nil, fmt.Errorf("unknown migration topology %q", name)
+}
+
+// each island sends to the next one
+type ringTopology struct {
+	n int
+}
+
+func (t *ringTopology) Neighbors(id, epoch int) []int {
+	if t.n < 2 {
+		return nil
+	}
+	return []int{(id + 1) % t.n}
+}
+
+// each island sends to the previous and next ones
+type biringTopology struct {
+	n int
+}
+
+func (t *biringTopology) Neighbors(id, epoch int) []int {
+	if t.n < 2 {
+		return nil
+	}
+	m1 := (id + t.n - 1) % t.n
+	p1 := (id + 1) % t.n
+	if m1 == p1 {
+		return []int{p1}
+	}
+	return []int{m1, p1}
+}
+
+// island 0 is the hub, it sends to everyone and everyone sends to it
+type starTopology struct {
+	n int
+}
+
+func (t *starTopology) Neighbors(id, epoch int) []int {
+	if t.n < 2 {
+		return nil
+	}
+	if id != 0 {
+		return []int{0}
+	}
+	nbrs := make([]int, 0, t.n-1)
+	for i := 1; i < t.n; i++ {
+		nbrs = append(nbrs, i)
+	}
+	return nbrs
+}
+
+// islands sit on a wrapped rows x cols grid and send to
+// the four islands above, below, left and right of them
+type torusTopology struct {
+	rows, cols int
+}
+
+func newTorusTopology(n int) *torusTopology {
+	// the most square grid that n islands fill exactly
+	rows := int(math.Sqrt(float64(n)))
+	for rows > 1 && n%rows != 0 {
+		rows--
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return &torusTopology{rows, n / rows}
+}
+
+func (t *torusTopology) Neighbors(id, epoch int) []int {
+	r, c := id/t.cols, id%t.cols
+	cands := []int{
+		((r+t.rows-1)%t.rows)*t.cols + c,
+		((r+1)%t.rows)*t.cols + c,
+		r*t.cols + (c+t.cols-1)%t.cols,
+		r*t.cols + (c+1)%t.cols,
+	}
+
+	// small grids wrap onto the same island
+	nbrs := make([]int, 0, 4)
+	for _, n := range cands {
+		if n != id && !hasInt(nbrs, n) {
+			nbrs = append(nbrs, n)
+		}
+	}
+	return nbrs
+}
+
+// every island sends to every other island
+type fullTopology struct {
+	n int
+}
+
+func (t *fullTopology) Neighbors(id, epoch int) []int {
+	nbrs := make([]int, 0, t.n)
+	for i := 0; i < t.n; i++ {
+		if i != id {
+			nbrs = append(nbrs, i)
+		}
+	}
+	return nbrs
+}
+
+// each island sends to degree random islands,
+// the graph is re-drawn every epoch
+type randomTopology struct {
+	n      int
+	degree int
+	seed   int64
+}
+
+func (t *randomTopology) Neighbors(id, epoch int) []int {
+	// islands draw their own edges without coordinating,
+	// so the draw depends only on the seed, epoch and id
+	rng := rand.New(rand.NewSource(islandSeed(t.seed, epoch*t.n+id)))
+
+	nbrs := make([]int, 0, t.degree)
+	for _, i := range rng.Perm(t.n) {
+		if len(nbrs) == t.degree {
+			break
+		}
+		if i != id {
+			nbrs = append(nbrs, i)
+		}
+	}
+	return nbrs
+}
+
+func hasInt(list []int, v int) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
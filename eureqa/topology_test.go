@@ -0,0 +1,63 @@
+package eureqa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopologyNeighbors(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, id    int
+		neighbor []int
+	}{
+		{"ring", 4, 3, []int{0}},
+		{"ring", 1, 0, nil},
+		{"biring", 4, 0, []int{3, 1}},
+		{"biring", 2, 1, []int{0}},
+		{"star", 5, 0, []int{1, 2, 3, 4}},
+		{"star", 5, 3, []int{0}},
+		{"full", 4, 2, []int{0, 1, 3}},
+		// 6 islands are a 2x3 grid
+		{"torus", 6, 0, []int{3, 2, 1}},
+		{"torus", 6, 4, []int{1, 3, 5}},
+		// and 7 a 1x7 ring
+		{"torus", 7, 0, []int{6, 1}},
+		{"torus", 9, 4, []int{1, 7, 3, 5}},
+	}
+	for _, test := range tests {
+		topo, err := newTopology(test.name, test.n, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := topo.Neighbors(test.id, 0); !reflect.DeepEqual(got, test.neighbor) {
+			t.Errorf("%s of %d: island %d sends to %v, want %v", test.name, test.n, test.id, got, test.neighbor)
+		}
+	}
+	if _, err := newTopology("mesh", 4, 1); err == nil {
+		t.Error("no error for an unknown topology")
+	}
+}
+
+func TestRandomTopology(t *testing.T) {
+	const n = 8
+	a, _ := newTopology("random", n, 5)
+	b, _ := newTopology("random", n, 5)
+	changed := false
+	for epoch := 0; epoch < 10; epoch++ {
+		for id := 0; id < n; id++ {
+			nbrs := a.Neighbors(id, epoch)
+			if len(nbrs) != 2 || nbrs[0] == nbrs[1] || hasInt(nbrs, id) {
+				t.Errorf("island %d at epoch %d sends to %v", id, epoch, nbrs)
+			}
+			// the draw is the same for everyone who asks
+			if !reflect.DeepEqual(nbrs, b.Neighbors(id, epoch)) {
+				t.Errorf("island %d at epoch %d: draws differ", id, epoch)
+			}
+			changed = changed || !reflect.DeepEqual(nbrs, a.Neighbors(id, epoch+1))
+		}
+	}
+	if !changed {
+		t.Error("the graph is never re-drawn")
+	}
+}
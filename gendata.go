@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/verdverm/go-eureqa/eureqa"
+)
+
+// genData is the gen-data subcommand, which writes a data file
+// sampled from a formula, for tests whose equation is known:
+//
+//	go-eureqa gen-data -f "y = x^2 + sin(z)" -vars x=-3:3,z=0:1 -sample lhs -n 200 -noise 0.01 -out data/F5.data
+func genData(args []string) {
+	fs := flag.NewFlagSet("gen-data", flag.ExitOnError)
+	formula := fs.String("f", "", "\"name = formula\" to sample, or a formula whose output is named like f(x,y)")
+	vars := fs.String("vars", "", "comma separated variable ranges, such as x=-3:3,z=0:1, the order of the columns")
+	sample := fs.String("sample", "grid", "sampling: grid, uniform or lhs (latin hypercube)")
+	n := fs.Int("n", 100, "points to sample, per variable for grid")
+	noise := fs.Float64("noise", 0, "standard deviation of the noise added to the output, 0 for none")
+	noiseType := fs.String("noisetype", "gaussian", "noise: gaussian, added to the output, or relative, a fraction of it")
+	seed := fs.Int64("seed", 0, "random seed, 0 picks one from the clock")
+	out := fs.String("out", "", "data file to write, standard output when empty")
+	fs.Parse(args)
+
+	if *formula == "" || *vars == "" {
+		log.Fatalln("gen-data needs a formula, -f, and variable ranges, -vars")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	sampling, err := eureqa.ParseSampling(*sample)
+	if err != nil {
+		log.Fatal(err)
+	}
+	noiseKind, err := eureqa.ParseNoise(*noiseType)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := &eureqa.GenOptions{
+		Formula:  *formula,
+		Sampling: sampling,
+		N:        *n,
+		Noise:    noiseKind,
+		Level:    *noise,
+		Rng:      rand.New(rand.NewSource(*seed)),
+	}
+	for _, spec := range strings.Split(*vars, ",") {
+		vr, err := eureqa.ParseVarRange(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Vars = append(opts.Vars, vr)
+	}
+
+	data, err := eureqa.GenerateData(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	// a comment, so the file records how to make it again
+	fmt.Fprintf(w, "# gen-data -f %q -vars %s -sample %s -n %d -noise %g -noisetype %s -seed %d\n",
+		*formula, *vars, *sample, *n, *noise, *noiseType, *seed)
+	if err := data.Write(w); err != nil {
+		log.Fatal(err)
+	}
+}
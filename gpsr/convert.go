@@ -0,0 +1,242 @@
+package gpsr
+
+import (
+	"fmt"
+
+	expr "damd/go-symexpr"
+	probs "damd/problems"
+
+	symexpr "github.com/verdverm/go-symexpr"
+
+	"github.com/verdverm/go-eureqa/eureqa"
+)
+
+// DataSetProblem is the default ProblemFunc. Each experiment of data
+// is a Train data set, which are also the Test ones as the DataSet has
+// no held out rows, and the rows skipped for missing values are left out.
+func DataSetProblem(data *eureqa.DataSet, p *eureqa.Params) (*probs.ExprProblem, *Weights, error) {
+	tree, err := treeParams(&p.Tree, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nexp := data.Experiments()
+	pts := make([][]probs.Point, nexp)
+	wts := make([][]float64, nexp)
+	for r := 0; r < data.Length(); r++ {
+		if data.Skipped(r) {
+			continue
+		}
+		x := data.Experiment(r)
+		pnt := probs.NewPoint(data.Dimensions(), 1)
+		pnt.SetIndeps(data.Input(r))
+		pnt.SetDepnds([]float64{data.Output(r)})
+		pts[x] = append(pts[x], *pnt)
+		wts[x] = append(wts[x], data.Weight(r))
+	}
+
+	sets := make([]*probs.PointSet, 0, nexp)
+	W := new(Weights)
+	for x := 0; x < nexp; x++ {
+		if len(pts[x]) == 0 {
+			continue
+		}
+		ps := new(probs.PointSet)
+		ps.SetIndepNames(data.VarNames())
+		ps.SetDepndNames([]string{data.OutName()})
+		ps.SetSysNames(data.SysNames())
+		ps.SetPoints(pts[x])
+		if len(data.SysNames()) > 0 {
+			// every row of an experiment has its system values
+			for r := 0; r < data.Length(); r++ {
+				if data.Experiment(r) == x {
+					ps.SetSysVals(data.SysVals(r))
+					break
+				}
+			}
+		}
+		sets = append(sets, ps)
+		W.Train = append(W.Train, wts[x])
+	}
+	if len(sets) == 0 {
+		return nil, nil, fmt.Errorf("gpsr: no rows to search")
+	}
+	W.Test = W.Train
+
+	prob := new(probs.ExprProblem)
+	prob.Name = data.OutName()
+	prob.SearchType = probs.ExprBenchmark
+	prob.Train = sets
+	prob.Test = sets
+	prob.TreeCfg = tree
+	return prob, W, nil
+}
+
+// treeParams copies the island search's tree settings to gpsr's
+func treeParams(tp *eureqa.TreeParams, data *eureqa.DataSet) (*probs.TreeParams, error) {
+	var err error
+	conv := func(ts []symexpr.ExprType) []expr.ExprType {
+		out := make([]expr.ExprType, 0, len(ts))
+		for _, t := range ts {
+			et, ok := exprType(t)
+			if !ok {
+				err = fmt.Errorf("gpsr: no equation node for %v", t)
+				continue
+			}
+			out = append(out, et)
+		}
+		return out
+	}
+
+	g := new(probs.TreeParams)
+	g.MaxSize, g.MaxDepth = tp.MaxSize, tp.MaxDepth
+	g.MinSize, g.MinDepth = tp.MinSize, tp.MinDepth
+	g.RootsT = conv(tp.RootsT)
+	g.NodesT = conv(tp.NodesT)
+	g.LeafsT = conv(tp.LeafsT)
+	g.NonTrigT = conv(tp.NonTrigT)
+	g.DoSimp = tp.DoSimp
+
+	g.UsableVars = make([]int, data.Dimensions())
+	for d := range g.UsableVars {
+		g.UsableVars[d] = d
+	}
+	g.NumDim = data.Dimensions()
+	g.NumSys = len(data.SysNames())
+	if g.NumSys > 0 {
+		g.LeafsT = append(g.LeafsT, expr.SYSTEM)
+	}
+	return g, err
+}
+
+// exprType is gpsr's node type for t, the two libraries
+// share the built in nodes but not the user primitives
+func exprType(t symexpr.ExprType) (expr.ExprType, bool) {
+	switch t {
+	case symexpr.TIME:
+		return expr.TIME, true
+	case symexpr.VAR:
+		return expr.VAR, true
+	case symexpr.CONSTANT:
+		return expr.CONSTANT, true
+	case symexpr.CONSTANTF:
+		return expr.CONSTANTF, true
+	case symexpr.SYSTEM:
+		return expr.SYSTEM, true
+	case symexpr.NEG:
+		return expr.NEG, true
+	case symexpr.ABS:
+		return expr.ABS, true
+	case symexpr.SQRT:
+		return expr.SQRT, true
+	case symexpr.SIN:
+		return expr.SIN, true
+	case symexpr.COS:
+		return expr.COS, true
+	case symexpr.TAN:
+		return expr.TAN, true
+	case symexpr.EXP:
+		return expr.EXP, true
+	case symexpr.LOG:
+		return expr.LOG, true
+	case symexpr.ADD:
+		return expr.ADD, true
+	case symexpr.MUL:
+		return expr.MUL, true
+	case symexpr.DIV:
+		return expr.DIV, true
+	}
+	return 0, false
+}
+
+// SymExpr is the default ExprFunc. Constants take their values
+// from coeff, and it is nil for nodes the island search lacks.
+func SymExpr(e expr.Expr, coeff []float64) symexpr.Expr {
+	switch n := e.(type) {
+	case *expr.Time:
+		return symexpr.NewTime()
+	case *expr.Var:
+		return symexpr.NewVar(n.P)
+	case *expr.Constant:
+		if n.P >= len(coeff) {
+			return nil
+		}
+		return symexpr.NewConstantF(coeff[n.P])
+	case *expr.ConstantF:
+		return symexpr.NewConstantF(n.F)
+	case *expr.System:
+		return symexpr.NewSystem(n.P)
+
+	case *expr.Neg:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewNeg(c)
+		}
+	case *expr.Abs:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewAbs(c)
+		}
+	case *expr.Sqrt:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewSqrt(c)
+		}
+	case *expr.Sin:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewSin(c)
+		}
+	case *expr.Cos:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewCos(c)
+		}
+	case *expr.Tan:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewTan(c)
+		}
+	case *expr.Exp:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewExp(c)
+		}
+	case *expr.Log:
+		if c := SymExpr(n.C, coeff); c != nil {
+			return symexpr.NewLog(c)
+		}
+
+	case *expr.Add:
+		add := symexpr.NewAdd()
+		for _, c := range n.CS {
+			sc := SymExpr(c, coeff)
+			if sc == nil {
+				return nil
+			}
+			add.Insert(sc)
+		}
+		return add
+	case *expr.Mul:
+		mul := symexpr.NewMul()
+		for _, c := range n.CS {
+			sc := SymExpr(c, coeff)
+			if sc == nil {
+				return nil
+			}
+			mul.Insert(sc)
+		}
+		return mul
+	case *expr.Div:
+		num, den := SymExpr(n.Numer, coeff), SymExpr(n.Denom, coeff)
+		if num == nil || den == nil {
+			return nil
+		}
+		return symexpr.NewDiv(num, den)
+	case *expr.PowI:
+		// small whole powers are products, as in a formula
+		base := SymExpr(n.Base, coeff)
+		if base == nil || n.Power < 1 || n.Power > 8 {
+			return nil
+		}
+		mul := symexpr.NewMul()
+		for i := 0; i < n.Power; i++ {
+			mul.Insert(base.Clone())
+		}
+		return mul
+	}
+	return nil
+}
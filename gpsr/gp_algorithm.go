@@ -0,0 +1,233 @@
+package gpsr
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	expr "damd/go-symexpr"
+	probs "damd/problems"
+
+	symexpr "github.com/verdverm/go-symexpr"
+
+	"github.com/verdverm/go-eureqa/eureqa"
+)
+
+// The damd packages gpsr is written against have their own data and
+// expression types. ProblemFunc builds a gpsr problem from a DataSet,
+// with the weights of its points or nil, and ExprFunc converts gpsr's
+// equations and their coefficients back, nil if it can't. DataSetProblem
+// and SymExpr are the default ones.
+type (
+	ProblemFunc func(data *eureqa.DataSet, p *eureqa.Params) (*probs.ExprProblem, *Weights, error)
+	ExprFunc    func(e expr.Expr, coeff []float64) symexpr.Expr
+)
+
+var (
+	toProblem ProblemFunc
+	toExpr    ExprFunc
+)
+
+// Enable registers GpsrSearch as the "gpsr" eureqa.Algorithm, converting
+// with problem and conv. It can't run without them, so gpsr isn't
+// registered until whoever links in the damd packages calls Enable,
+// as the go-eureqa command does when built with -tags gpsr.
+func Enable(problem ProblemFunc, conv ExprFunc) {
+	if problem == nil || conv == nil {
+		panic("gpsr: Enable needs both converters")
+	}
+	toProblem, toExpr = problem, conv
+	eureqa.Register("gpsr", func() eureqa.Algorithm { return new(gpsrAlgorithm) })
+}
+
+// Weights weight the points of a problem's Train and Test data sets,
+// [data set][point], in the train and test errors, and the point subsets
+// sample the Train points in proportion to them. Nil weights are all 1.
+type Weights struct {
+	Train, Test [][]float64
+}
+
+// check is an error unless the weights fit the data sets of prob
+func (W *Weights) check(prob *probs.ExprProblem) error {
+	for d, w := range W.Train {
+		if w != nil && (d >= len(prob.Train) || len(w) != prob.Train[d].NumPoints()) {
+			return fmt.Errorf("gpsr: %d train weights for data set %d", len(w), d)
+		}
+	}
+	for d, w := range W.Test {
+		if w != nil && (d >= len(prob.Test) || len(w) != prob.Test[d].NumPoints()) {
+			return fmt.Errorf("gpsr: %d test weights for data set %d", len(w), d)
+		}
+	}
+	return nil
+}
+
+// pointWeight is the weight of point p of data set d, 1 without weights
+func pointWeight(W [][]float64, d, p int) float64 {
+	if d >= len(W) || W[d] == nil {
+		return 1
+	}
+	return W[d][p]
+}
+
+// gpsrAlgorithm runs a GpsrSearch as a eureqa.Algorithm
+type gpsrAlgorithm struct {
+	last *probs.ExprReportArray // latest report of the search
+}
+
+func (A *gpsrAlgorithm) Run(ctx context.Context, data *eureqa.DataSet, p *eureqa.Params, obs ...eureqa.Observer) (*eureqa.Result, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	prob, weights, err := toProblem(data, p)
+	if err != nil {
+		return nil, err
+	}
+	if weights == nil {
+		weights = new(Weights)
+	}
+	if err := weights.check(prob); err != nil {
+		return nil, err
+	}
+	logdir, err := ioutil.TempDir("", "gpsr")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(logdir)
+
+	GS := new(GpsrSearch)
+	GS.weights = weights
+	GS.cnfg = configFromParams(p)
+	if GS.cnfg.seed == 0 {
+		GS.cnfg.seed = time.Now().UnixNano()
+	}
+	if p.AlgoConfig != "" {
+		GS.ParseConfig(p.AlgoConfig)
+	}
+
+	for _, o := range obs {
+		o.OnSearchInit(GS.cnfg.numEqnIsles)
+	}
+	comm := new(probs.ExprProblemComm)
+	comm.Cmds = make(chan int)
+	comm.Gen = make(chan [2]int, 16)
+	comm.Rpts = make(chan *probs.ExprReportArray, 16)
+	GS.Init(nil, prob, logdir+"/", comm)
+	for _, o := range obs {
+		o.OnSearchStart()
+	}
+	go GS.Run()
+
+	// GpsrSearch only reports the average generation of its eqn
+	// islands, so the observers see every island at that generation
+	cancelled := false
+	for stop := false; !stop; {
+		select {
+		case gen := <-comm.Gen:
+			stats := A.genStats()
+			for i := 0; i < GS.cnfg.numEqnIsles; i++ {
+				for _, o := range obs {
+					o.OnGenerationEnd(i, gen[1]-1, stats)
+				}
+			}
+			stop = gen[1] >= GS.cnfg.maxGen
+		case rpt := <-comm.Rpts:
+			A.last = rpt
+		case <-ctx.Done():
+			stop, cancelled = true, true
+		}
+	}
+
+	// Run acknowledges the stop by echoing it back, keep draining
+	// the other channels so GpsrSearch never blocks on the way out
+	for sent := false; !sent; {
+		select {
+		case comm.Cmds <- -1:
+			sent = true
+		case <-comm.Gen:
+		case rpt := <-comm.Rpts:
+			A.last = rpt
+		}
+	}
+	for acked := false; !acked; {
+		select {
+		case <-comm.Cmds:
+			acked = true
+		case <-comm.Gen:
+		case rpt := <-comm.Rpts:
+			A.last = rpt
+		}
+	}
+
+	res := eureqa.NewResult(A.eqns(), data)
+	res.Seed = GS.cnfg.seed
+	res.Cancelled = cancelled
+	for _, o := range obs {
+		o.OnSearchDone(A.eqns(), cancelled)
+	}
+	return res, nil
+}
+
+// eqns converts the latest report to eureqa equations
+func (A *gpsrAlgorithm) eqns() []*eureqa.Eqn {
+	if A.last == nil {
+		return nil
+	}
+	eqns := make([]*eureqa.Eqn, 0, len(*A.last))
+	for _, r := range *A.last {
+		if r == nil {
+			continue
+		}
+		if e := toExpr(r.Expr(), r.Coeff()); e != nil {
+			eqns = append(eqns, eureqa.NewEqn(e, r.TrainError()))
+		}
+	}
+	return eqns
+}
+
+func (A *gpsrAlgorithm) genStats() *eureqa.GenStats {
+	stats := new(eureqa.GenStats)
+	for i, e := range A.eqns() {
+		if i == 0 || e.Err() < stats.BestErr {
+			stats.BestErr = e.Err()
+			stats.BestSize = e.Size()
+		}
+		stats.FrontSize++
+	}
+	return stats
+}
+
+// configFromParams maps the island search settings onto gpsr's,
+// the subset islands have no counterpart so they get fixed sizes
+func configFromParams(p *eureqa.Params) gpsrConfig {
+	var C gpsrConfig
+	C.seed = p.Seed
+	C.maxGen = p.Gens
+	C.gpsrRptEpoch = 1
+	C.gpsrRptCount = p.RptSize
+
+	C.numEqnIsles = p.Islands
+	C.eqnMigEpoch = p.MigEpoch
+	C.eqnMigCount = p.MigCount
+	C.eqnRptEpoch = 1
+	C.eqnRptCount = p.RptSize
+	C.numEqns = p.PopSize
+	C.eqnBroodSz = 1
+	C.eqnCrossRate = p.CrossRate
+	C.eqnMutateRate = p.MutateRate
+	C.evalWorkers = p.EvalWorkers
+
+	C.numSSetIsles = 2
+	C.ssetMigEpoch = p.MigEpoch
+	C.ssetMigCount = p.MigCount
+	C.ssetRptEpoch = 1
+	C.ssetRptCount = 4
+	C.numSSets = 16
+	C.ssetSize = 32
+	C.ssetBroodSz = 1
+	C.ssetCrossRate = p.CrossRate
+	C.ssetMutateRate = p.MutateRate
+	return C
+}
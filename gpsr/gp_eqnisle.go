@@ -31,6 +31,8 @@ type EqnIsland struct {
 	crossRate  float64
 	mutateRate float64
 
+	evalWorkers int
+
 	eqnMigEpoch int
 	eqnMigCount int
 	eqnRptEpoch int
@@ -41,8 +43,9 @@ type EqnIsland struct {
 	trie    *IpreNode
 
 	// externally supplied
-	prob  *probs.ExprProblem
-	ssets []*probs.PntSubset
+	prob    *probs.ExprProblem
+	weights *Weights
+	ssets   []*probs.PntSubset
 
 	// internal data
 	// -------------
@@ -76,6 +79,9 @@ func (isle *EqnIsland) copyInConfig(gs *GpsrSearch) {
 	gp := gs.cnfg
 
 	isle.prob = gs.prob
+	isle.weights = gs.weights
+	// seeded in island order, from the search's generator
+	isle.rng = rand.New(rand.NewSource(gs.rng.Int63()))
 	isle.treecfg = gp.treecfg.Clone()
 	isle.numEqns = gp.numEqns
 	isle.eqnBroodSz = gp.eqnBroodSz
@@ -85,6 +91,7 @@ func (isle *EqnIsland) copyInConfig(gs *GpsrSearch) {
 	isle.eqnRptCount = gp.eqnRptCount
 	isle.crossRate = gp.eqnCrossRate
 	isle.mutateRate = gp.eqnMutateRate
+	isle.evalWorkers = gp.evalWorkers
 
 	isle.eqnCmd = gs.eqnCmd[isle.id]
 	isle.eqnRpt = gs.eqnRpt[isle.id]
@@ -105,9 +112,6 @@ func (isle *EqnIsland) copyInConfig(gs *GpsrSearch) {
 func (isle *EqnIsland) init() {
 	fmt.Println("Initializing EqnIsland ", isle.id)
 
-	// create random number generator
-	isle.rng = rand.New(rand.NewSource(rand.Int63()))
-
 	// init logs
 	isle.initLogs()
 	isle.mainLog.Println("Initializing EqnIsland ", isle.id)
@@ -240,7 +244,7 @@ func (isle *EqnIsland) eval() {
 	// isle.mainLog.Println("Evaluating EqnIsland ", isle.id, isle.gen)
 
 	for i := 0; i < isle.numEqns; i++ {
-		calcEqnPredErr(isle.brood[i], isle.ssets, isle.prob)
+		calcEqnPredErr(isle.brood[i], isle.ssets, isle.prob, isle.evalWorkers)
 		for j, e := range isle.brood[i] {
 			if badEqnFilterPred(e) {
 				isle.brood[i][j] = nil
@@ -502,7 +506,7 @@ func (isle *EqnIsland) initEval() {
 
 	// evaluate new Exprs in brood
 	for i := 0; i < isle.numEqns; i++ {
-		calcEqnTrainErr(isle.brood[i], isle.prob)
+		calcEqnTrainErr(isle.brood[i], isle.prob, isle.weights.Train, isle.evalWorkers)
 		for j, e := range isle.brood[i] {
 			isle.brood[i][j].SetPredError(isle.brood[i][j].TrainError())
 			if badEqnFilterTrain(e) {
@@ -7,11 +7,17 @@ import (
 
 	// expr "damd/go-symexpr"
 	probs "damd/problems"
+
+	"github.com/verdverm/go-eureqa/peval"
 )
 
-func calcEqnPredErr(eqns probs.ExprReportArray, ssets []*probs.PntSubset, EP *probs.ExprProblem) {
+// the calcEqn*Err functions spread eqns over at most workers goroutines,
+// the train and test errors weight the points by W, [data set][point],
+// and the subsets of the predicted error are already sampled by weight
+func calcEqnPredErr(eqns probs.ExprReportArray, ssets []*probs.PntSubset, EP *probs.ExprProblem, workers int) {
 	XN := EP.SearchVar
-	for e, E := range eqns {
+	peval.For(len(eqns), workers, func(e int) {
+		E := eqns[e]
 		TNP := 0
 		errSum := 0.0
 		hitSum := 0
@@ -53,22 +59,26 @@ func calcEqnPredErr(eqns probs.ExprReportArray, ssets []*probs.PntSubset, EP *pr
 		}
 		eqns[e].SetPredError(errSum / float64(TNP))
 		eqns[e].SetPredScore(hitSum)
-	}
+	})
 	return
 }
 
-func calcEqnTrainErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
+func calcEqnTrainErr(eqns probs.ExprReportArray, EP *probs.ExprProblem, W [][]float64, workers int) {
 	XN := EP.SearchVar
-	for e, E := range eqns {
-		TNP := 0
+	peval.For(len(eqns), workers, func(e int) {
+		E := eqns[e]
+		TNP := 0.0 // total weight
 		errSum := 0.0
 		hitSum := 0
 		perrSum := make([]float64, len(EP.Train))
 		phitSum := make([]int, len(EP.Train))
 		for d, D := range EP.Train {
 			DNP := D.NumPoints()
-			TNP += DNP
+			DW := 0.0
 			for p := 0; p < DNP; p++ {
+				w := pointWeight(W, d, p)
+				DW += w
+				TNP += w
 				in := D.Point(p)
 				var ret float64
 				switch EP.SearchType {
@@ -83,7 +93,7 @@ func calcEqnTrainErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
 				}
 
 				if math.IsNaN(ret) {
-					TNP--
+					TNP -= w
 					continue
 				}
 
@@ -91,7 +101,7 @@ func calcEqnTrainErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
 				err := (in.Depnd(XN) - ret)
 
 				if math.IsNaN(err) {
-					TNP--
+					TNP -= w
 					continue
 				}
 
@@ -103,34 +113,38 @@ func calcEqnTrainErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
 					phitSum[d]++
 				}
 
-				errSum += aerr
-				perrSum[d] += aerr
+				errSum += w * aerr
+				perrSum[d] += w * aerr
 			}
-			perrSum[d] /= float64(DNP)
+			perrSum[d] /= DW
 		}
-		eqns[e].SetTrainError(errSum / float64(TNP))
+		eqns[e].SetTrainError(errSum / TNP)
 		eqns[e].SetTrainScore(hitSum)
 		eqns[e].SetTrainErrorZ(perrSum)
 		eqns[e].SetTrainScoreZ(phitSum)
-	}
+	})
 	return
 }
 
-func calcEqnTestErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
+func calcEqnTestErr(eqns probs.ExprReportArray, EP *probs.ExprProblem, W [][]float64, workers int) {
 	XN := EP.SearchVar
-	for e, E := range eqns {
+	peval.For(len(eqns), workers, func(e int) {
+		E := eqns[e]
 		if E == nil {
-			continue
+			return
 		}
-		TNP := 0
+		TNP := 0.0 // total weight
 		errSum := 0.0
 		hitSum := 0
 		perrSum := make([]float64, len(EP.Test))
 		phitSum := make([]int, len(EP.Test))
 		for d, D := range EP.Test {
 			DNP := D.NumPoints()
-			TNP += DNP
+			DW := 0.0
 			for p := 0; p < DNP; p++ {
+				w := pointWeight(W, d, p)
+				DW += w
+				TNP += w
 				in := D.Point(p)
 
 				var ret float64
@@ -147,7 +161,7 @@ func calcEqnTestErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
 				err := (in.Depnd(XN) - ret)
 
 				if math.IsNaN(err) {
-					TNP--
+					TNP -= w
 					continue
 				}
 				aerr := math.Abs(err)
@@ -158,15 +172,15 @@ func calcEqnTestErr(eqns probs.ExprReportArray, EP *probs.ExprProblem) {
 					phitSum[d]++
 				}
 
-				errSum += aerr
-				perrSum[d] += aerr
+				errSum += w * aerr
+				perrSum[d] += w * aerr
 			}
-			perrSum[d] /= float64(DNP)
+			perrSum[d] /= DW
 		}
-		eqns[e].SetTestError(errSum / float64(TNP))
+		eqns[e].SetTestError(errSum / TNP)
 		eqns[e].SetTestScore(hitSum)
 		eqns[e].SetTestErrorZ(perrSum)
 		eqns[e].SetTestScoreZ(phitSum)
-	}
+	})
 	return
 }
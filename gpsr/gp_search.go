@@ -22,6 +22,7 @@ import (
 // and instructs in where to find the sub-searches
 type gpsrConfig struct {
 	// search params
+	seed         int64 // the islands derive theirs from it, 0 picks one at random
 	maxGen       int
 	gpsrRptEpoch int
 	gpsrRptCount int
@@ -39,6 +40,7 @@ type gpsrConfig struct {
 	eqnBroodSz    int
 	eqnCrossRate  float64
 	eqnMutateRate float64
+	evalWorkers   int
 
 	// ssetIsland params
 	numSSetIsles   int
@@ -84,6 +86,8 @@ func gpsrConfigParser(field, value string, config interface{}) (err error) {
 		GC.eqnCrossRate, err = strconv.ParseFloat(value, 64)
 	case "EQNMUTATERATE":
 		GC.eqnMutateRate, err = strconv.ParseFloat(value, 64)
+	case "EVALWORKERS":
+		GC.evalWorkers, err = strconv.Atoi(value)
 
 	case "NUMSSETISLES":
 		GC.numSSetIsles, err = strconv.Atoi(value)
@@ -134,6 +138,8 @@ type GpsrSearch struct {
 	rng  *rand.Rand
 	stop bool
 
+	weights *Weights // of prob's points
+
 	// comm upside
 	commup *probs.ExprProblemComm
 
@@ -215,7 +221,11 @@ func (GS *GpsrSearch) ParseConfig(filename string) {
 
 func (GS *GpsrSearch) Init(done chan int, prob *probs.ExprProblem, logdir string, input interface{}) {
 	fmt.Printf("Init'n GPSR\n--------------\n")
-	GS.rng = rand.New(rand.NewSource(rand.Int63()))
+	seed := GS.cnfg.seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	GS.rng = rand.New(rand.NewSource(seed))
 
 	GS.minError = 10000000.0
 
@@ -227,6 +237,9 @@ func (GS *GpsrSearch) Init(done chan int, prob *probs.ExprProblem, logdir string
 
 	// copy in data and common config options
 	GS.prob = prob
+	if GS.weights == nil {
+		GS.weights = new(Weights) // all 1
+	}
 	if GS.cnfg.treecfg == nil {
 		GS.cnfg.treecfg = GS.prob.TreeCfg.Clone()
 	}
@@ -620,7 +633,7 @@ func (GS *GpsrSearch) accumExprReports() {
 	}
 
 	// evaluate union members on test data
-	calcEqnTestErr(union, GS.prob)
+	calcEqnTestErr(union, GS.prob, GS.weights.Test, GS.cnfg.evalWorkers)
 
 	errSum, errCnt := 0.0, 0
 	for _, r := range union {
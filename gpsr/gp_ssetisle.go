@@ -71,6 +71,10 @@ type SSetIsland struct {
 	prob *probs.ExprProblem
 	pnts *PntStatsArray2d
 
+	// running sums of the Train weights, to sample
+	// points by weight, nil for unweighted data sets
+	cumWeights [][]float64
+
 	// communications stuff
 	ssetCmd chan int
 	pntErrs chan *PntStatsArray2d //, eqns we are optimizing subsets for ???
@@ -119,6 +123,20 @@ func (isle *SSetIsland) copyInConfig(gs *GpsrSearch) {
 	isle.pnts = gs.pnts
 	isle.logDir = gs.logDir + fmt.Sprintf("sisle%d/", isle.id)
 	isle.prob = gs.prob
+	// seeded in island order, from the search's generator
+	isle.rng = rand.New(rand.NewSource(gs.rng.Int63()))
+	isle.cumWeights = make([][]float64, len(gs.prob.Train))
+	for d, W := range gs.weights.Train {
+		if W == nil {
+			continue
+		}
+		isle.cumWeights[d] = make([]float64, len(W))
+		sum := 0.0
+		for p, w := range W {
+			sum += w
+			isle.cumWeights[d][p] = sum
+		}
+	}
 
 	isle.numSSets = gp.numSSets
 	isle.ssetBroodSz = gp.ssetBroodSz
@@ -150,9 +168,6 @@ func (isle *SSetIsland) copyInConfig(gs *GpsrSearch) {
 func (isle *SSetIsland) init() {
 	fmt.Println("Initializing SSetIsland ", isle.id)
 
-	// create random number generator
-	isle.rng = rand.New(rand.NewSource(rand.Int63()))
-
 	// open logs
 	isle.initLogs()
 	isle.mainLog.Println("Initializing SSetIsland ", isle.id)
@@ -393,7 +408,12 @@ func (isle *SSetIsland) breed() {
 
 				if isle.rng.Float64() < isle.mutateRate {
 					pos := isle.rng.Intn(SS)
-					newP := isle.rng.Intn(NP)
+					var newP int
+					if isle.cumWeights[d] != nil {
+						newP = isle.weightedPoint(d, isle.rng.Float64())
+					} else {
+						newP = isle.rng.Intn(NP)
+					}
 					sset.indices[pos] = newP
 				}
 				isle.brood[d][i][b] = sset
@@ -470,7 +490,11 @@ func (isle *SSetIsland) initSubsets() {
 				isle.brood[d][i][j].dataset = d
 				isle.brood[d][i][j].indices = make([]int, isle.ssetSize)
 				for k := 0; k < isle.ssetSize; k++ {
-					isle.brood[d][i][j].indices[k] = rand.Intn(npts - 1)
+					if isle.cumWeights[d] != nil {
+						isle.brood[d][i][j].indices[k] = isle.weightedPoint(d, isle.rng.Float64())
+					} else {
+						isle.brood[d][i][j].indices[k] = isle.rng.Intn(npts - 1)
+					}
 				}
 				isle.ssetLog.Println(isle.brood[d][i][j])
 			}
@@ -479,6 +503,19 @@ func (isle *SSetIsland) initSubsets() {
 	}
 }
 
+// weightedPoint picks a point of data set d with a chance in
+// proportion to its weight, u is uniform in [0,1)
+func (isle *SSetIsland) weightedPoint(d int, u float64) int {
+	cum := isle.cumWeights[d]
+	x := u * cum[len(cum)-1]
+	// the first running sum past x, never a zero weight point
+	p := sort.Search(len(cum), func(i int) bool { return cum[i] > x })
+	if p == len(cum) {
+		p = len(cum) - 1
+	}
+	return p
+}
+
 func newPntStats(EP *probs.ExprProblem) *PntStatsArray2d {
 	PS := make(PntStatsArray2d, len(EP.Train))
 	for i := 0; i < len(EP.Train); i++ {
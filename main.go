@@ -1,58 +1,338 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
 	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
 	"time"
 
-	. "github.com/verdverm/go-symexpr"
+	"github.com/verdverm/go-eureqa/eureqa"
 )
 
 var data_dir = "data/"
 
-var fn = flag.String("data", "F1.data", "data file to analyze")
+var algo = flag.String("algo", "island", "search algorithm, one of those registered")
+var algoCfg = flag.String("algocfg", "", "settings file for algorithms which read their own, such as gpsr")
+var fn = flag.String("data", "F1.data", "data file to analyze, or comma separated files of experiments sharing one model")
+var lenient = flag.Bool("lenient", false, "skip bad data rows with a warning instead of failing")
+var cache = flag.Bool("cache", false, "keep parsed data in a binary .cache file beside each data file, read from it while the file is unchanged")
+var delim = flag.String("delim", "", "data column separator: comma, semicolon, tab or space, detected when empty")
+var syncRpt = flag.Bool("sync", false, "islands report synchronously, in lockstep generations")
+var topo = flag.String("topo", "ring", "migration topology: ring, biring, star, torus, full or random")
+var migEpoch = flag.Int("migepoch", 5, "generations between migrations, 0 disables migration")
+var migInt = flag.Duration("migint", 0, "wall clock time between migrations, overrides -migepoch")
+var migCount = flag.Int("migcount", 2, "equations sent to each neighbor")
+var migSel = flag.String("migsel", "best", "emigrant selection: best, random, tournament or novel")
+var migRep = flag.String("migrep", "none", "residents displaced by immigrants: none, worst, random or similar")
+var out = flag.String("out", "", "also save the final equations to this file")
+var profiles = flag.String("profiles", "", "island profiles: empty for uniform islands, spread, or a profiles file")
+var workers = flag.Int("workers", 1, "goroutines evaluating offspring within each island")
+var stagWin = flag.Int("stagwin", 0, "restart islands which haven't improved in this many generations, 0 disables")
+var split = flag.String("split", "", "hold out test rows: random, block (the last rows) or every (k-th row), none when empty")
+var testFrac = flag.Float64("testfrac", 0.2, "fraction of the rows held out by -split random or block")
+var testEvery = flag.Int("testevery", 5, "k for -split every")
+var testData = flag.String("testdata", "", "separate test data file, instead of -split")
+var testWeights = flag.String("testweights", "", "file of row weights for -testdata, like -weights")
+var target = flag.String("target", "", "output column by name, the last column when empty")
+var exclude = flag.String("exclude", "", "comma separated columns which aren't inputs")
+var weightCol = flag.String("weight", "", "column weighting the rows, not an input")
+var weightsFile = flag.String("weights", "", "file of row weights, one per line, instead of -weight")
+var timeRole = flag.String("time", "", "column of the rows' times, not an input")
+var derived listFlag
+
+func init() {
+	flag.Var(&derived, "derive", "derived input column such as \"r = sqrt(x^2 + y^2)\", may be repeated")
+}
+
+var missing = flag.String("missing", "drop", "missing value strategies: a default of drop, mean, median, interp or skip, then column=strategy pairs, comma separated; mean and median fill from every row, so -split rejects them")
+var timeCol = flag.String("timecol", "", "column interp runs along, -time's or the row order when empty")
+var scale = flag.String("scale", "none", "scaling for the search: a default of none, standard or minmax, then column=scaling pairs, comma separated")
+var funcs = flag.String("funcs", "", "comma separated primitives to add to the equations, such as tanh,sigmoid,erf")
+var seed = flag.Int64("seed", 0, "master random seed, 0 picks one from the clock")
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen-data" {
+		genData(os.Args[2:])
+		return
+	}
+	// engines such as gpsr register from init, after the flags are made
+	flag.Lookup("algo").Usage = fmt.Sprintf("search algorithm, one of %v", eureqa.Algorithms())
 	flag.Parse()
-	rand.Seed(time.Now().UnixNano())
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rand.Seed(*seed)
 
 	fmt.Println("Hello Gophers\n-----------------")
+	fmt.Println("Seed:", *seed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+	go interrupts(sigs, cancel, os.Exit)
+
+	srp := params()
+	switch *profiles {
+	case "":
+	case "spread":
+		srp.Profiles = eureqa.SpreadProfiles(srp, srp.Islands)
+	default:
+		profs, err := eureqa.ReadProfilesFile(*profiles, srp)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srp.Profiles = profs
+	}
+	// before reading the data, which can be slow
+	if err := srp.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	switch *split {
+	case "random", "block":
+		if !(*testFrac > 0 && *testFrac < 1) {
+			log.Fatalln("Test fraction must be between 0 and 1: ", *testFrac)
+		}
+	case "every":
+		if *testEvery < 2 {
+			log.Fatalln("Test rows must be every k-th with k at least 2: ", *testEvery)
+		}
+	case "", "none":
+	default:
+		log.Fatalln("Unknown data split: ", *split)
+	}
 
-	srp := defaultParams()
-	srch := newSearch(srp)
-	srch.initSearch()
-	srch.runSearch()
+	alg, err := eureqa.NewAlgorithm(*algo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ropts := &eureqa.ReadOptions{
+		Delim:   parseDelim(*delim),
+		Lenient: *lenient,
+		Missing: parseMissing(*missing),
+		Target:  *target,
+		Weight:  *weightCol,
+		Time:    *timeRole,
+		Derived: derived,
+		Cache:   *cache,
+
+		WeightsFile: *weightsFile,
+	}
+	if *exclude != "" {
+		ropts.Exclude = strings.Split(*exclude, ",")
+	}
+	files := strings.Split(*fn, ",")
+	for i := range files {
+		files[i] = data_dir + files[i]
+	}
+	data, err := eureqa.ReadExperiments(files, ropts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, w := range data.Warnings() {
+		fmt.Println("Skipped:", w)
+	}
+	if mr := data.Missing(); mr.Rows > 0 {
+		fmt.Printf("Missing values in %d rows, %d dropped, %d skipped in evaluation\n", mr.Rows, mr.Dropped, mr.Skipped)
+		if mr.NaNRows > 0 {
+			fmt.Printf("  %d of the rows have NaN values, read as missing\n", mr.NaNRows)
+		}
+		for c, n := range mr.Cells {
+			if n > 0 {
+				fmt.Printf("  %s: %d\n", mr.Names[c], n)
+			}
+		}
+	}
+	data, test := splitData(data, ropts)
+	if test != nil {
+		fmt.Printf("Training on %d rows, testing on %d\n", data.Length(), test.Length())
+	}
+
+	// the search runs on scaled data, and its front is
+	// taken back to the units of the data file
+	search, scaler := data, (*eureqa.Scaler)(nil)
+	if sp := parseScale(*scale); sp != nil {
+		if search, scaler, err = data.Normalize(sp); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	res, err := alg.Run(ctx, search, srp, eureqa.NewConsoleObserver(srp.Islands))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if scaler != nil {
+		res = scaler.Unscale(res, data, srp.Fitness)
+	}
+	if test != nil {
+		res.Test(test, srp.Fitness)
+	}
+	if data.Experiments() > 1 {
+		res.FitExperiments(data, srp.Fitness)
+	}
 
 	fmt.Println("Final Results\n-----------------")
-	srch.printBestEqns()
+	res.Print(os.Stdout)
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res.Print(f)
+		f.Close()
+	}
 }
 
-func defaultParams() *SR_Params {
+// listFlag collects the values of a repeated flag
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, "; ") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
 
-	var srp SR_Params
-	srp.DataFN = data_dir + *fn
-	srp.Gens = 100
-	srp.Islands = 8
+// interrupts cancels the search on the first Ctrl-C
+// so the results so far are kept, and exits on the second
+func interrupts(sigs <-chan os.Signal, cancel context.CancelFunc, exit func(code int)) {
+	<-sigs
+	fmt.Println("\nInterrupted, stopping the search (Ctrl-C again to force exit)")
+	cancel()
 
-	srp.PopSize = 50
-	srp.RptSize = 10
-	srp.CrossRate = 0.75
-	srp.MutateRate = 0.2
+	<-sigs
+	fmt.Println("\nForced exit")
+	exit(1)
+}
+
+func parseDelim(name string) rune {
+	switch name {
+	case "":
+		return 0
+	case "comma", ",":
+		return ','
+	case "semicolon", ";":
+		return ';'
+	case "tab", "\t":
+		return '\t'
+	case "space", " ":
+		return ' '
+	default:
+		log.Fatalln("Unknown data delimiter: ", name)
+	}
+	return 0
+}
+
+// splitData holds out the test rows chosen by -split or
+// -testdata, test is nil when there are none
+func splitData(data *eureqa.DataSet, ropts *eureqa.ReadOptions) (train, test *eureqa.DataSet) {
+	if *testData != "" {
+		// the training weights don't fit the test rows
+		topts := *ropts
+		topts.WeightsFile = *testWeights
+		test, err := eureqa.ReadDataSetFile(data_dir+*testData, &topts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := data.SameColumns(test); err != nil {
+			log.Fatal(err)
+		}
+		return data, test
+	}
+	var err error
+	switch *split {
+	case "", "none":
+		return data, nil
+	case "random":
+		train, test, err = data.SplitRandom(*testFrac, rand.New(rand.NewSource(*seed)))
+	case "block":
+		train, test, err = data.SplitBlock(*testFrac)
+	case "every":
+		train, test, err = data.SplitEvery(*testEvery)
+	default:
+		log.Fatalln("Unknown data split: ", *split)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return train, test
+}
+
+// parseMissing reads -missing, such as "mean,y=drop,x2=interp"
+func parseMissing(spec string) *eureqa.MissingPolicy {
+	mp := &eureqa.MissingPolicy{Columns: make(map[string]eureqa.MissingStrategy), TimeCol: *timeCol}
+	for i, part := range strings.Split(spec, ",") {
+		eq := strings.Index(part, "=")
+		if eq < 0 && i > 0 {
+			log.Fatalln("Missing value default must come first: ", part)
+		}
+		ms, err := eureqa.ParseMissingStrategy(part[eq+1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if eq >= 0 {
+			mp.Columns[part[:eq]] = ms
+		} else {
+			mp.Default = ms
+		}
+	}
+	return mp
+}
+
+// parseScale reads -scale like -missing, nil when nothing is scaled
+func parseScale(spec string) *eureqa.ScalePolicy {
+	sp := &eureqa.ScalePolicy{Columns: make(map[string]eureqa.Scaling)}
+	scaled := false
+	for i, part := range strings.Split(spec, ",") {
+		eq := strings.Index(part, "=")
+		if eq < 0 && i > 0 {
+			log.Fatalln("Scaling default must come first: ", part)
+		}
+		sc, err := eureqa.ParseScaling(part[eq+1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if eq >= 0 {
+			sp.Columns[part[:eq]] = sc
+		} else {
+			sp.Default = sc
+		}
+		scaled = scaled || sc != eureqa.SCALE_NONE
+	}
+	if !scaled {
+		return nil
+	}
+	return sp
+}
 
-	p := &srp.treep
-	p.MaxSize = 50
-	p.MinSize = 3
-	p.MaxDepth = 6
-	p.MinDepth = 1
+// params are the default search settings changed by the flags
+func params() *eureqa.Params {
+	srp := eureqa.DefaultParams()
+	srp.Seed = *seed
+	srp.AlgoConfig = *algoCfg
+	srp.SyncReports = *syncRpt
+	srp.EvalWorkers = *workers
+	srp.StagWindow = *stagWin
 
-	p.RootsT = []ExprType{ADD, MUL}
-	p.NodesT = []ExprType{VAR, CONSTANTF, ADD, NEG, MUL, DIV, COS, SIN}
-	p.NonTrigT = []ExprType{VAR, CONSTANTF, ADD, NEG, MUL, DIV}
-	p.LeafsT = []ExprType{VAR, CONSTANTF}
+	srp.Topology = *topo
+	srp.MigEpoch = *migEpoch
+	srp.MigInterval = *migInt
+	srp.MigCount = *migCount
+	var err error
+	if srp.MigSelect, err = eureqa.ParseMigSelect(*migSel); err != nil {
+		log.Fatal(err)
+	}
+	if srp.MigReplace, err = eureqa.ParseMigReplace(*migRep); err != nil {
+		log.Fatal(err)
+	}
 
-	p.DoSimp = true
-	p.SRules = DefaultRules()
+	if *funcs != "" {
+		if err = srp.UsePrimitives(strings.Split(*funcs, ",")...); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	return &srp
+	return srp
 }
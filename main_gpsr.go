@@ -0,0 +1,12 @@
+//go:build gpsr
+// +build gpsr
+
+package main
+
+import "github.com/verdverm/go-eureqa/gpsr"
+
+// gpsr needs the damd packages, so it is only
+// built in with: go build -tags gpsr
+func init() {
+	gpsr.Enable(gpsr.DataSetProblem, gpsr.SymExpr)
+}
@@ -0,0 +1,16 @@
+//go:build gpsr
+// +build gpsr
+
+package main
+
+import (
+	"testing"
+
+	"github.com/verdverm/go-eureqa/eureqa"
+)
+
+func TestGpsrRegistered(t *testing.T) {
+	if _, err := eureqa.NewAlgorithm("gpsr"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/verdverm/go-eureqa/eureqa"
+)
+
+func TestInterrupts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal)
+	exited := make(chan int, 1)
+	go interrupts(sigs, cancel, func(code int) { exited <- code })
+
+	sigs <- os.Interrupt
+	<-ctx.Done() // the first cancels the search
+	select {
+	case <-exited:
+		t.Fatal("exited on the first interrupt")
+	default:
+	}
+
+	sigs <- os.Interrupt
+	if code := <-exited; code != 1 {
+		t.Errorf("exit code %d on the second interrupt", code)
+	}
+}
+
+func TestParseMissing(t *testing.T) {
+	mp := parseMissing("mean,y=drop,x2=interp")
+	want := map[string]eureqa.MissingStrategy{"y": eureqa.MISS_DROP, "x2": eureqa.MISS_INTERP}
+	if mp.Default != eureqa.MISS_MEAN || !reflect.DeepEqual(mp.Columns, want) {
+		t.Errorf("default %v, columns %v", mp.Default, mp.Columns)
+	}
+	if mp := parseMissing("skip"); mp.Default != eureqa.MISS_SKIP || len(mp.Columns) != 0 {
+		t.Errorf("default %v, columns %v", mp.Default, mp.Columns)
+	}
+}
@@ -0,0 +1,41 @@
+// Package peval spreads independent evaluations over a bounded
+// number of goroutines. It is shared by the island engine and gpsr.
+package peval
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// For calls f(i) for every i in [0,n) on at most workers goroutines,
+// returning when all calls are done. Calls run in no particular order,
+// so f(i) must only write to state belonging to i. That keeps the
+// results the same whatever the number of workers.
+func For(n, workers int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
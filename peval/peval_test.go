@@ -0,0 +1,36 @@
+package peval
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		n, workers int
+	}{
+		{0, 4},
+		{1, 4},
+		{3, 8},   // fewer indices than workers
+		{100, 0}, // serial
+		{100, -2},
+		{100, 1},
+		{100, 7},
+	}
+	for _, tt := range tests {
+		calls := make([]int32, tt.n)
+		For(tt.n, tt.workers, func(i int) {
+			atomic.AddInt32(&calls[i], 1)
+		})
+		for i, c := range calls {
+			if c != 1 {
+				t.Errorf("For(%d, %d): index %d ran %d times, want once", tt.n, tt.workers, i, c)
+			}
+		}
+	}
+}
+
+func TestForZeroNeverCalls(t *testing.T) {
+	For(0, 4, func(i int) { t.Errorf("called with %d for n=0", i) })
+	For(-1, 4, func(i int) { t.Errorf("called with %d for n=-1", i) })
+}